@@ -0,0 +1,92 @@
+package kid
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Binder binds request to a struct.
+type Binder interface {
+	Bind(c *Ctx, out interface{}) error
+}
+
+// BinderFunc is an adapter to use a function as Binder.
+type BinderFunc func(c *Ctx, out interface{}) error
+
+// Bind calls f(c, out).
+func (f BinderFunc) Bind(c *Ctx, out interface{}) error {
+	return f(c, out)
+}
+
+// JsonBinder binds json body.
+var JsonBinder Binder = BinderFunc(func(c *Ctx, out interface{}) error {
+	body, err := c.ReadBody()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+})
+
+// XmlBinder binds xml body.
+var XmlBinder Binder = BinderFunc(func(c *Ctx, out interface{}) error {
+	body, err := c.ReadBody()
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(body, out)
+})
+
+// FormBinder binds url-encoded form body and query.
+var FormBinder Binder = BinderFunc(func(c *Ctx, out interface{}) error {
+	if err := c.request.ParseForm(); err != nil {
+		return c.bodyError(err)
+	}
+	return unmarshalForm(c.request.Form, out)
+})
+
+// MultipartBinder binds multipart form body.
+var MultipartBinder Binder = BinderFunc(func(c *Ctx, out interface{}) error {
+	if err := c.request.ParseMultipartForm(32 << 20); err != nil {
+		return c.bodyError(err)
+	}
+	return unmarshalForm(c.request.MultipartForm.Value, out)
+})
+
+// Binders registered by default, keyed by media type.
+var defaultBinders = map[string]Binder{
+	"application/json":                  JsonBinder,
+	"application/xml":                   XmlBinder,
+	"text/xml":                          XmlBinder,
+	"application/x-www-form-urlencoded": FormBinder,
+	"multipart/form-data":               MultipartBinder,
+}
+
+// binder gets the binder of content type. Media types with +json or +xml
+// suffix use the json or xml binder, and requests without body use the form
+// binder to bind query.
+func (k *Kid) binder(contentType string, method string) Binder {
+	if contentType == "" {
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodDelete {
+			return FormBinder
+		}
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	if binder, ok := k.binders[mediaType]; ok {
+		return binder
+	}
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return k.binders["application/json"]
+	case strings.HasSuffix(mediaType, "+xml"):
+		return k.binders["application/xml"]
+	}
+	return nil
+}
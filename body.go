@@ -0,0 +1,37 @@
+package kid
+
+import "io"
+
+// bodyReader wraps request's body and stops reading when limit is exceeded.
+type bodyReader struct {
+	io.ReadCloser
+
+	// Max bytes can be read, no limit if it is not positive.
+	limit int64
+
+	read     int64
+	exceeded bool
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	if b.limit <= 0 {
+		return b.ReadCloser.Read(p)
+	}
+	if b.exceeded {
+		return 0, ErrBodyTooLarge
+	}
+
+	// Read one more byte than remaining to know if limit is exceeded.
+	remaining := b.limit - b.read
+	if int64(len(p)) > remaining+1 {
+		p = p[:remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > remaining {
+		b.read = b.limit
+		b.exceeded = true
+		return int(remaining), ErrBodyTooLarge
+	}
+	b.read += int64(n)
+	return n, err
+}
@@ -1,14 +1,87 @@
 package kid
 
+import "time"
+
+// PathPolicy decides how to handle requests whose path is not canonical.
+type PathPolicy int
+
+const (
+	// PathLenient serves non-canonical paths like the canonical ones.
+	PathLenient PathPolicy = iota
+
+	// PathStrict returns 404 for non-canonical paths.
+	PathStrict
+
+	// PathRedirect redirects non-canonical paths to the canonical ones with
+	// 301 for GET and HEAD requests, or 308 for other requests.
+	PathRedirect
+)
+
 type Config struct {
 	// ErrorHandler is executed when an error is returned from kid.HandlerFunc.
 	//
 	// Default: DefaultErrorHandler
 	ErrorHandler ErrorHandlerFunc
+
+	// ReadTimeout is the maximum duration for reading the entire request, including the body.
+	//
+	// Default: 0 (no timeout)
+	ReadTimeout time.Duration
+
+	// ReadHeaderTimeout is the amount of time allowed to read request headers.
+	// ReadTimeout is used if it is zero.
+	//
+	// Default: 0 (no timeout)
+	ReadHeaderTimeout time.Duration
+
+	// WriteTimeout is the maximum duration before timing out writes of the response.
+	//
+	// Default: 0 (no timeout)
+	WriteTimeout time.Duration
+
+	// IdleTimeout is the maximum amount of time to wait for the next request when keep-alives are enabled.
+	// ReadTimeout is used if it is zero.
+	//
+	// Default: 0 (no timeout)
+	IdleTimeout time.Duration
+
+	// MaxHeaderBytes controls the maximum number of bytes the server will read parsing the request header.
+	//
+	// Default: http.DefaultMaxHeaderBytes (1 MB)
+	MaxHeaderBytes int
+
+	// BodyLimit is the maximum number of bytes of request's body,
+	// 413 Request Entity Too Large is returned when it is exceeded.
+	//
+	// Default: 0 (no limit)
+	BodyLimit int64
+
+	// PathPolicy decides how to handle requests whose path is not canonical.
+	// The canonical path has no empty, "." or ".." segment, and has a trailing
+	// slash only if the route is registered with one, like "/a" or "/a/".
+	//
+	// Default: PathLenient
+	PathPolicy PathPolicy
+
+	// CaseInsensitive makes static segments of routes match case-insensitively,
+	// the canonical path uses the case of the registered route.
+	//
+	// Default: false
+	CaseInsensitive bool
+
+	// Locales are supported locales of validation messages, like "en", "zh" or "zh_Hant_TW".
+	// The locale is picked by request's Accept-Language header, the first one
+	// is used if none matches.
+	//
+	// Default: []string{"en"}
+	Locales []string
 }
 
 func setDefaultConfig(k *Kid) {
 	if k.config.ErrorHandler == nil {
 		k.config.ErrorHandler = DefaultErrorHandler
 	}
+	if len(k.config.Locales) == 0 {
+		k.config.Locales = []string{"en"}
+	}
 }
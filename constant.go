@@ -3,8 +3,11 @@ package kid
 // Http header
 const (
 	HeaderRequestId                     = "X-Request-ID"
+	HeaderAllow                         = "Allow"
+	HeaderAcceptLanguage                = "Accept-Language"
 	HeaderContentDisposition            = "Content-Disposition"
 	HeaderContentType                   = "Content-Type"
+	HeaderContentLength                 = "Content-Length"
 	HeaderLocation                      = "Location"
 	HeaderAuthorization                 = "Authorization"
 	HeaderWWWAuthenticate               = "WWW-Authenticate"
@@ -2,28 +2,43 @@ package kid
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strconv"
-	"strings"
 	"time"
 
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 )
 
-var validate = validator.New()
-
+// Ctx holds the request and response of a request.
+//
+// Ctx is pooled and reused by other requests after handlers return, so it must
+// not be retained or used by goroutines after that. Use Ctx.Copy to get one
+// that can outlive the request.
 type Ctx struct {
+	kid     *Kid
 	writer  http.ResponseWriter
 	request *http.Request
 
-	params  map[string]string
-	rawBody []byte
+	// Shallow copy of the incoming request whose body is limited,
+	// the caller's request is not modified.
+	req http.Request
+
+	params    []param
+	paramsMap map[string]string
+	body      bodyReader
+	rawBody   []byte
+	bodyRead  bool
+	bodyErr   error
+
+	// Writer used when a GET handler serves a HEAD request.
+	head headWriter
 
 	status   int
 	store    map[string]interface{}
@@ -31,23 +46,71 @@ type Ctx struct {
 	index    int
 }
 
-func newCtx(w http.ResponseWriter, r *http.Request) *Ctx {
-	rawBody, _ := io.ReadAll(r.Body)
-	r.Body = io.NopCloser(bytes.NewReader(rawBody))
+// reset prepares ctx for a new request.
+func (c *Ctx) reset(k *Kid, w http.ResponseWriter, r *http.Request) {
+	c.kid = k
+	c.writer = w
+	c.req = *r
+	c.request = &c.req
+
+	c.params = c.params[:0]
+	c.paramsMap = nil
+	c.body = bodyReader{ReadCloser: r.Body, limit: k.config.BodyLimit}
+	c.req.Body = &c.body
+	c.rawBody = nil
+	c.bodyRead = false
+	c.bodyErr = nil
 
-	context := &Ctx{
-		writer:  w,
-		request: r,
+	c.head = headWriter{}
 
-		params:  make(map[string]string),
-		rawBody: rawBody,
+	c.status = http.StatusOK
+	for key := range c.store {
+		delete(c.store, key)
+	}
+	c.handlers = nil
+	c.index = -1
+}
 
-		status:   http.StatusOK,
-		store:    make(map[string]interface{}),
-		handlers: make([]HandlerFunc, 0),
-		index:    -1,
+// removeMultipartForm removes temporary files of multipart forms parsed on copies of r,
+// net/http only removes the ones of r itself.
+func (c *Ctx) removeMultipartForm(r *http.Request) {
+	if form := c.req.MultipartForm; form != nil && form != r.MultipartForm {
+		form.RemoveAll()
+	}
+	if form := c.request.MultipartForm; form != nil && form != r.MultipartForm && form != c.req.MultipartForm {
+		form.RemoveAll()
 	}
-	return context
+}
+
+// Copy returns a copy of ctx that can be used after handlers return, like in
+// goroutines. Responses can not be sent with the copy.
+//
+// Request's body is buffered before copying, because the reader of it is reused
+// by other requests.
+func (c *Ctx) Copy() *Ctx {
+	c.ReadBody()
+
+	cp := &Ctx{
+		kid: c.kid,
+		req: *c.request,
+
+		params:   make([]param, len(c.params)),
+		body:     bodyReader{exceeded: c.body.exceeded},
+		rawBody:  c.rawBody,
+		bodyRead: c.bodyRead,
+		bodyErr:  c.bodyErr,
+
+		status: c.status,
+		store:  make(map[string]interface{}, len(c.store)),
+		index:  len(c.handlers),
+	}
+	cp.req.Body = io.NopCloser(bytes.NewReader(cp.rawBody))
+	cp.request = &cp.req
+	copy(cp.params, c.params)
+	for key, value := range c.store {
+		cp.store[key] = value
+	}
+	return cp
 }
 
 // Next starts the next middleware.
@@ -62,6 +125,9 @@ func (c *Ctx) Next() error {
 
 // Set sets some value to ctx.
 func (c *Ctx) Set(key string, value interface{}) {
+	if c.store == nil {
+		c.store = make(map[string]interface{})
+	}
 	c.store[key] = value
 }
 
@@ -70,6 +136,43 @@ func (c *Ctx) Get(key string) interface{} {
 	return c.store[key]
 }
 
+// Context returns request's context.
+func (c *Ctx) Context() context.Context {
+	return c.request.Context()
+}
+
+// SetContext replaces request's context, like one with a timeout.
+func (c *Ctx) SetContext(ctx context.Context) {
+	c.request = c.request.WithContext(ctx)
+}
+
+// Deadline implements context.Context with request's context.
+func (c *Ctx) Deadline() (deadline time.Time, ok bool) {
+	return c.Context().Deadline()
+}
+
+// Done implements context.Context with request's context, it is closed when
+// the client disconnects or the context is canceled.
+func (c *Ctx) Done() <-chan struct{} {
+	return c.Context().Done()
+}
+
+// Err implements context.Context with request's context.
+func (c *Ctx) Err() error {
+	return c.Context().Err()
+}
+
+// Value implements context.Context, it returns the value set by Ctx.Set if key
+// is a string, else the value of request's context.
+func (c *Ctx) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok {
+		if value, ok := c.store[k]; ok {
+			return value
+		}
+	}
+	return c.Context().Value(key)
+}
+
 // Method returns request's method.
 func (c *Ctx) Method() string {
 	return c.request.Method
@@ -82,12 +185,18 @@ func (c *Ctx) Url() *url.URL {
 
 // Params gets all router path params.
 func (c *Ctx) Params() map[string]string {
-	return c.params
+	if c.paramsMap == nil {
+		c.paramsMap = make(map[string]string, len(c.params))
+		for _, p := range c.params {
+			c.paramsMap[p.key] = p.value
+		}
+	}
+	return c.paramsMap
 }
 
 // GetParam gets a router path param value by key.
 func (c *Ctx) GetParam(key string, defaultValue ...string) string {
-	return getValue(c.params[key], defaultValue...)
+	return getValue(getParam(c.params, key), defaultValue...)
 }
 
 // Query gets request's Query.
@@ -137,67 +246,116 @@ func (c *Ctx) FormFile(key string) (*multipart.FileHeader, error) {
 	return fh, err
 }
 
-// Body gets request's raw body.
+// Body gets request's raw body, errors of reading it are ignored, use ReadBody to get them.
 func (c *Ctx) Body() []byte {
-	return c.rawBody
+	body, _ := c.ReadBody()
+	return body
 }
 
-// BodyParser parsers body to any struct.
-func (c *Ctx) BodyParser(out interface{}) error {
-	ctype := c.GetHeader(HeaderContentType)
+// ReadBody gets request's raw body and the error of reading it, like ErrBodyTooLarge.
+// The body is read and buffered at the first call, and the same bytes are returned after that.
+func (c *Ctx) ReadBody() ([]byte, error) {
+	if !c.bodyRead {
+		c.bodyRead = true
+		c.rawBody, c.bodyErr = io.ReadAll(c.request.Body)
+		c.request.Body = io.NopCloser(bytes.NewReader(c.rawBody))
+	}
+	return c.rawBody, c.bodyErr
+}
 
-	parsed := false
+// bodyError returns ErrBodyTooLarge if body limit is exceeded, else returns err.
+func (c *Ctx) bodyError(err error) error {
+	if c.body.exceeded {
+		return ErrBodyTooLarge
+	}
+	return err
+}
 
-	switch {
-	case strings.HasPrefix(ctype, "application/json"):
-		err := json.Unmarshal(c.Body(), out)
-		if err != nil {
-			return err
-		}
-		parsed = true
-	case strings.HasPrefix(ctype, "application/x-www-form-urlencoded") ||
-		c.Method() == "HEAD" ||
-		c.Method() == "GET" ||
-		c.Method() == "DELETE":
-		err := c.request.ParseForm()
-		if err != nil {
-			return err
-		}
-		err = unmarshalForm(c.request.Form, out)
-		if err != nil {
-			return err
+// BodyParser parses body to any struct with the binder registered for
+// request's content type, then validates it.
+func (c *Ctx) BodyParser(out interface{}) error {
+	binder := c.kid.binder(c.GetHeader(HeaderContentType), c.Method())
+	if binder == nil {
+		return NewError(http.StatusUnsupportedMediaType, "415 Unsupported Media Type", nil)
+	}
+
+	if err := binder.Bind(c, out); err != nil {
+		if e, ok := err.(*Error); ok {
+			return e
 		}
-		parsed = true
-	case strings.HasPrefix(ctype, "multipart/form-data"):
-		err := c.request.ParseMultipartForm(32 << 20)
-		if err != nil {
-			return err
+		return NewError(http.StatusBadRequest, fmt.Sprintf("400 Bad Request: %s", err), nil)
+	}
+
+	return c.validate(out)
+}
+
+// Bind binds the request to a struct pointed by out and validates it.
+//
+// Fields are filled from body, query, header, cookie and param tags in this order,
+// a later source overrides an earlier one, so path params have the highest precedence.
+// Body is bound by the binder of request's content type when the request has a body.
+func (c *Ctx) Bind(out interface{}) error {
+	if c.request.ContentLength != 0 {
+		binder := c.kid.binder(c.GetHeader(HeaderContentType), c.Method())
+		if binder == nil {
+			return NewError(http.StatusUnsupportedMediaType, "415 Unsupported Media Type", nil)
 		}
-		err = unmarshalForm(c.request.MultipartForm.Value, out)
-		if err != nil {
-			return err
+		if err := binder.Bind(c, out); err != nil {
+			if e, ok := err.(*Error); ok {
+				return e
+			}
+			return NewError(http.StatusBadRequest, fmt.Sprintf("400 Bad Request: %s", err), nil)
 		}
-		parsed = true
-	case strings.HasPrefix(ctype, "text/xml") ||
-		strings.HasPrefix(ctype, "application/xml"):
-		err := xml.Unmarshal(c.Body(), out)
-		if err != nil {
-			return err
+	}
+
+	cookies := make(url.Values)
+	for _, cookie := range c.request.Cookies() {
+		cookies.Add(cookie.Name, cookie.Value)
+	}
+	params := make(url.Values, len(c.params))
+	for _, p := range c.params {
+		params.Set(p.key, p.value)
+	}
+
+	sources := []struct {
+		tag    string
+		values url.Values
+	}{
+		{"query", c.Query()},
+		{"header", url.Values(c.request.Header)},
+		{"cookie", cookies},
+		{"param", params},
+	}
+	for _, source := range sources {
+		if err := unmarshalValues(source.values, out, source.tag); err != nil {
+			return NewError(http.StatusBadRequest, fmt.Sprintf("400 Bad Request: %s", err), nil)
 		}
-		parsed = true
 	}
 
-	if parsed {
-		err := validate.Struct(out)
-		if err != nil {
-			if _, ok := err.(*validator.InvalidValidationError); ok {
-				return nil
-			}
-			return err
+	return c.validate(out)
+}
+
+// validate validates a struct pointed by out with the validator, validation failures
+// are returned as *kid.Error with status 422 and messages in request's locale.
+func (c *Ctx) validate(out interface{}) error {
+	if err := c.kid.validator.Struct(out); err != nil {
+		if _, ok := err.(*validator.InvalidValidationError); ok {
+			return nil
 		}
+		return validationError(err, c.translator())
 	}
+	return nil
+}
+
+// Locale gets the locale of validation messages picked by Accept-Language header.
+func (c *Ctx) Locale() string {
+	return c.translator().Locale()
+}
 
-	return NewError(http.StatusUnprocessableEntity, "422 Unprocessable Entity", nil)
+// translator gets the translator of request's locale.
+func (c *Ctx) translator() ut.Translator {
+	trans, _ := c.kid.translator.FindTranslator(acceptLanguages(c.GetHeader(HeaderAcceptLanguage))...)
+	return trans
 }
 
 // SetHeader sets a header.
@@ -0,0 +1,51 @@
+package kid
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestCtxRemoveMultipartForm(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	fw, err := mw.CreateFormFile("file", "upload.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(bytes.Repeat([]byte("a"), 1024))
+	mw.Close()
+
+	k := New()
+	k.Post("/upload", func(c *Ctx) error {
+		// Files are stored on disk when maxMemory is exceeded.
+		if err := c.request.ParseMultipartForm(0); err != nil {
+			return err
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) == 0 {
+			t.Error("got no temporary file, want the uploaded one")
+		}
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set(HeaderContentType, mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	k.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if req.MultipartForm != nil {
+		t.Error("caller's request is modified")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("got %d temporary files after the request, want 0", len(entries))
+	}
+}
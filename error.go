@@ -10,6 +10,9 @@ type ErrorHandlerFunc func(*Ctx, error) error
 
 var errorLogger = NewLogger("HTTP Error")
 
+// ErrBodyTooLarge is returned when request's body is larger than Config.BodyLimit.
+var ErrBodyTooLarge = NewError(http.StatusRequestEntityTooLarge, "413 Request Entity Too Large", nil)
+
 // DefaultErrorHandler that process return errors or panic errors from handlers.
 var DefaultErrorHandler ErrorHandlerFunc = func(c *Ctx, err error) error {
 	message := fmt.Sprintf("%s %s", c.Method(), c.Url().RequestURI())
@@ -17,6 +20,13 @@ var DefaultErrorHandler ErrorHandlerFunc = func(c *Ctx, err error) error {
 		errorLogger.Error(c, message, map[string]interface{}{
 			"data": e.Data,
 		}, e)
+		// Send details of validation failures to clients as json.
+		if fields, ok := e.Data.([]FieldError); ok {
+			return c.Status(e.Status).Json(map[string]interface{}{
+				"message": e.Message,
+				"errors":  fields,
+			})
+		}
 		return c.Status(e.Status).String(e.Message)
 	} else {
 		errorLogger.Error(c, message, nil, err)
@@ -0,0 +1,368 @@
+package kid
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// formField is the reflection metadata of a struct field used by form binding.
+type formField struct {
+	index int
+	name  string
+
+	// Embedded struct without a tag, its fields are bound like the outer ones.
+	inline bool
+
+	// Don't set the field if the value is empty.
+	omitEmpty bool
+
+	// Value of default tag used when the key is missing and the field is unset.
+	defaultValue string
+	hasDefault   bool
+
+	// Layout of time_format tag used by time.Time, default: time.RFC3339
+	timeFormat string
+}
+
+// formFieldsKey is the key of formFieldsCache.
+type formFieldsKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// Cache of []formField keyed by struct type and tag name.
+var formFieldsCache sync.Map
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// getFormFields gets the cached fields of struct type t named by tag.
+// Only the form tag falls back to the field name for untagged fields.
+func getFormFields(t reflect.Type, tag string) []formField {
+	cacheKey := formFieldsKey{t, tag}
+	if fields, ok := formFieldsCache.Load(cacheKey); ok {
+		return fields.([]formField)
+	}
+
+	fields := make([]formField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		value, hasTag := sf.Tag.Lookup(tag)
+		if value == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(value, ",")
+		if tag == "header" {
+			name = http.CanonicalHeaderKey(name)
+		}
+
+		field := formField{
+			index:      i,
+			name:       _if(name != "", name, sf.Name),
+			omitEmpty:  contains(strings.Split(options, ","), "omitempty"),
+			timeFormat: getValue(sf.Tag.Get("time_format"), time.RFC3339),
+		}
+		field.defaultValue, field.hasDefault = sf.Tag.Lookup("default")
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && !hasTag && ft.Kind() == reflect.Struct {
+			field.inline = true
+		} else if !sf.IsExported() || (!hasTag && tag != "form") {
+			continue
+		}
+		fields = append(fields, field)
+	}
+
+	actual, _ := formFieldsCache.LoadOrStore(cacheKey, fields)
+	return actual.([]formField)
+}
+
+// normalizeFormKey converts keys like items[0][name], items[0].name and
+// tags[] to items.0.name, items.0.name and tags.
+func normalizeFormKey(key string) string {
+	key = strings.TrimSuffix(key, "[]")
+	if !strings.Contains(key, "[") {
+		return key
+	}
+	key = strings.ReplaceAll(key, "]", "")
+	return strings.ReplaceAll(key, "[", ".")
+}
+
+// unmarshalForm binds form data to a struct pointed by out, nested structs and
+// slices are bound with dotted or bracketed keys, like addr.city or items[0].name.
+func unmarshalForm(data url.Values, out interface{}) error {
+	return unmarshalValues(data, out, "form")
+}
+
+// unmarshalValues binds data to the fields of out named by tag.
+func unmarshalValues(data url.Values, out interface{}, tag string) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("kid: %s binding needs a non-nil pointer to struct", tag)
+	}
+
+	// Keys are merged in sorted order, so that values of keys like tags and tags[]
+	// are always in the same order.
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make(url.Values, len(data))
+	for _, key := range keys {
+		normalized := normalizeFormKey(key)
+		values[normalized] = append(values[normalized], data[key]...)
+	}
+	return decodeFormStruct(values, tag, "", v.Elem())
+}
+
+// hasFormPrefix reports whether any key of values starts with prefix followed by a dot.
+func hasFormPrefix(values url.Values, prefix string) bool {
+	for key := range values {
+		if strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// formIndexes returns sorted indexes of keys like prefix.0 or prefix.0.name.
+func formIndexes(values url.Values, prefix string) []int {
+	seen := make(map[int]bool)
+	indexes := make([]int, 0)
+	for key := range values {
+		if !strings.HasPrefix(key, prefix+".") {
+			continue
+		}
+		rest := key[len(prefix)+1:]
+		if dot := strings.IndexByte(rest, '.'); dot >= 0 {
+			rest = rest[:dot]
+		}
+		index, err := strconv.Atoi(rest)
+		if err != nil || index < 0 || seen[index] {
+			continue
+		}
+		seen[index] = true
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+func decodeFormStruct(values url.Values, tag, prefix string, v reflect.Value) error {
+	for _, field := range getFormFields(v.Type(), tag) {
+		fv := v.Field(field.index)
+		key := prefix + field.name
+
+		if field.inline {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					if !fv.CanSet() {
+						continue
+					}
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if err := decodeFormStruct(values, tag, prefix, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := decodeFormField(values, tag, key, fv, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeFormField(values url.Values, tag, key string, v reflect.Value, field formField) error {
+	t := v.Type()
+	elem := t
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+
+	// Nested struct like addr.city.
+	if elem.Kind() == reflect.Struct && !isFormScalar(elem) {
+		if !hasFormPrefix(values, key) {
+			return nil
+		}
+		if t.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(elem))
+			}
+			v = v.Elem()
+		}
+		return decodeFormStruct(values, tag, key+".", v)
+	}
+
+	// Slice like tags=a&tags=b, tags[0]=a or items[0].name.
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		items, ok := values[key]
+		indexes := formIndexes(values, key)
+		if !ok && len(indexes) == 0 {
+			if !field.hasDefault || !v.IsZero() {
+				return nil
+			}
+			items = strings.Split(field.defaultValue, ",")
+		}
+
+		if len(items) > 0 {
+			slice := reflect.MakeSlice(t, len(items), len(items))
+			for i, item := range items {
+				if err := setFormValue(slice.Index(i), item, field); err != nil {
+					return fmt.Errorf("kid: %s field %s: %w", tag, key, err)
+				}
+			}
+			v.Set(slice)
+			return nil
+		}
+
+		// Indexes only decide the order, so that the slice is not larger than the keys
+		// and rows removed from a form leave no gaps, like items[0] and items[2].
+		slice := reflect.MakeSlice(t, len(indexes), len(indexes))
+		for i, index := range indexes {
+			itemKey := key + "." + strconv.Itoa(index)
+			if err := decodeFormField(values, tag, itemKey, slice.Index(i), formField{timeFormat: field.timeFormat}); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	items, ok := values[key]
+	value := ""
+	switch {
+	case ok && len(items) > 0:
+		value = items[0]
+	case field.hasDefault && v.IsZero():
+		value = field.defaultValue
+	default:
+		return nil
+	}
+	if value == "" && field.omitEmpty {
+		return nil
+	}
+	if err := setFormValue(v, value, field); err != nil {
+		return fmt.Errorf("kid: %s field %s: %w", tag, key, err)
+	}
+	return nil
+}
+
+// isFormScalar reports whether struct type t is set from a single value.
+func isFormScalar(t reflect.Type) bool {
+	return t == timeType || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// setFormValue converts value to the type of v and sets it.
+func setFormValue(v reflect.Value, value string, field formField) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	if v.Type() == timeType {
+		if value == "" {
+			v.Set(reflect.Zero(timeType))
+			return nil
+		}
+		t, err := time.Parse(field.timeFormat, value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		if value == "" {
+			v.SetBool(false)
+			return nil
+		}
+		if value == "on" {
+			v.SetBool(true)
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value == "" {
+			v.SetInt(0)
+			return nil
+		}
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value == "" {
+			v.SetUint(0)
+			return nil
+		}
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		if value == "" {
+			v.SetFloat(0)
+			return nil
+		}
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		// []byte
+		v.SetBytes([]byte(value))
+	case reflect.Interface:
+		rv := reflect.ValueOf(value)
+		if !rv.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		v.Set(rv)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
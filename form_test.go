@@ -0,0 +1,152 @@
+package kid
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type formBase struct {
+	ID int `form:"id"`
+}
+
+type formAddress struct {
+	City string `form:"city"`
+	Zip  string `form:"zip"`
+}
+
+type formItem struct {
+	Name string `form:"name"`
+	Qty  uint   `form:"qty"`
+}
+
+type formTarget struct {
+	formBase
+	Name     string
+	Age      int8          `form:"age"`
+	Score    float64       `form:"score"`
+	Active   bool          `form:"active"`
+	Ratio    *float32      `form:"ratio"`
+	Born     time.Time     `form:"born" time_format:"2006-01-02"`
+	Timeout  time.Duration `form:"timeout"`
+	Tags     []string      `form:"tags"`
+	Numbers  []int         `form:"numbers"`
+	Address  formAddress   `form:"addr"`
+	Billing  *formAddress  `form:"billing"`
+	Items    []formItem    `form:"items"`
+	Page     int           `form:"page" default:"1"`
+	Sort     []string      `form:"sort" default:"name,age"`
+	Nickname string        `form:"nickname,omitempty" default:"anonymous"`
+	Skipped  string        `form:"-"`
+	Any      interface{}   `form:"any"`
+	Stringer fmt.Stringer  `form:"stringer"`
+}
+
+func TestUnmarshalForm(t *testing.T) {
+	ratio := float32(0.5)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    formTarget
+		wantErr bool
+	}{
+		{
+			name:  "scalars",
+			query: "Name=bob&age=-3&score=1.5&active=on&ratio=0.5&born=2024-01-02&timeout=1m&any=x",
+			want: formTarget{
+				Name: "bob", Age: -3, Score: 1.5, Active: true, Ratio: &ratio,
+				Born: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Timeout: time.Minute, Any: "x",
+				Page: 1, Sort: []string{"name", "age"}, Nickname: "anonymous",
+			},
+		},
+		{
+			name:  "embedded field",
+			query: "id=7",
+			want:  formTarget{formBase: formBase{ID: 7}, Page: 1, Sort: []string{"name", "age"}, Nickname: "anonymous"},
+		},
+		{
+			name:  "defaults are overridden",
+			query: "page=3&sort=id&nickname=neo",
+			want:  formTarget{Page: 3, Sort: []string{"id"}, Nickname: "neo"},
+		},
+		{
+			name:  "omitempty skips empty value",
+			query: "nickname=&page=",
+			want:  formTarget{Page: 0, Sort: []string{"name", "age"}, Nickname: ""},
+		},
+		{
+			name:  "repeated and bracketed slices",
+			query: "tags=a&tags[]=b&numbers[0]=1&numbers[1]=2",
+			want:  formTarget{Tags: []string{"a", "b"}, Numbers: []int{1, 2}, Page: 1, Sort: []string{"name", "age"}, Nickname: "anonymous"},
+		},
+		{
+			name:  "nested structs with dotted and bracketed keys",
+			query: "addr.city=Paris&addr[zip]=75001&billing[city]=Lyon",
+			want: formTarget{
+				Address: formAddress{City: "Paris", Zip: "75001"}, Billing: &formAddress{City: "Lyon"},
+				Page: 1, Sort: []string{"name", "age"}, Nickname: "anonymous",
+			},
+		},
+		{
+			name:  "slice of structs",
+			query: "items[1][name]=b&items[1].qty=2&items[0].name=a",
+			want: formTarget{
+				Items: []formItem{{Name: "a"}, {Name: "b", Qty: 2}},
+				Page:  1, Sort: []string{"name", "age"}, Nickname: "anonymous",
+			},
+		},
+		{
+			name:  "sparse indexes are compacted",
+			query: "items[0].name=a&items[2].name=c&numbers[5]=5",
+			want: formTarget{
+				Items: []formItem{{Name: "a"}, {Name: "c"}}, Numbers: []int{5},
+				Page: 1, Sort: []string{"name", "age"}, Nickname: "anonymous",
+			},
+		},
+		{
+			name:  "huge index",
+			query: "items[50000000].name=a",
+			want:  formTarget{Items: []formItem{{Name: "a"}}, Page: 1, Sort: []string{"name", "age"}, Nickname: "anonymous"},
+		},
+		{
+			name:  "skipped field",
+			query: "Skipped=x&-=x",
+			want:  formTarget{Page: 1, Sort: []string{"name", "age"}, Nickname: "anonymous"},
+		},
+		{name: "invalid int", query: "age=abc", wantErr: true},
+		{name: "int overflow", query: "age=300", wantErr: true},
+		{name: "invalid uint", query: "items[0].qty=-1", wantErr: true},
+		{name: "invalid bool", query: "active=maybe", wantErr: true},
+		{name: "invalid time", query: "born=02/01/2024", wantErr: true},
+		{name: "invalid duration", query: "timeout=soon", wantErr: true},
+		{name: "non-empty interface", query: "stringer=x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got formTarget
+			err = unmarshalForm(values, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalFormNeedsStructPointer(t *testing.T) {
+	var target formTarget
+	for _, out := range []interface{}{target, (*formTarget)(nil), new(int)} {
+		if err := unmarshalForm(url.Values{}, out); err == nil {
+			t.Errorf("got no error for %T", out)
+		}
+	}
+}
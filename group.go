@@ -1,57 +1,175 @@
 package kid
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+)
+
+// Methods that are registered by group.Any.
+var anyMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
 
 type group struct {
-	prefix string
-	kid    *Kid
+	host        string
+	prefix      string
+	kid         *Kid
+	parent      *group
+	middlewares []HandlerFunc
 }
 
-// Group creates a router group.
-func (g *group) Group(prefix string) *group {
+// Group creates a router group, middlewares are applied to routes registered through it.
+func (g *group) Group(prefix string, middlewares ...HandlerFunc) *group {
 	group := &group{
-		prefix: g.prefix + prefix,
-		kid:    g.kid,
+		host:        g.host,
+		prefix:      g.prefix + prefix,
+		kid:         g.kid,
+		parent:      g,
+		middlewares: middlewares,
 	}
+	g.kid.groups = append(g.kid.groups, group)
 	return group
 }
 
-func (g *group) addRoute(method string, comp string, handler HandlerFunc) {
-	pattern := g.prefix + comp
-	g.kid.router.addRoute(method, pattern, handler)
+// chain returns middlewares of g and its parents, from the app to g.
+func (g *group) chain() []HandlerFunc {
+	groups := make([]*group, 0)
+	for p := g; p != nil; p = p.parent {
+		groups = append(groups, p)
+	}
+
+	chain := make([]HandlerFunc, 0)
+	for i := len(groups) - 1; i >= 0; i-- {
+		chain = append(chain, groups[i].middlewares...)
+	}
+	return chain
+}
+
+// match reports whether the prefix of g matches the beginning of path, params in
+// the prefix match any segment. It returns the number of segments of the prefix.
+func (g *group) match(path string, foldCase bool) (int, bool) {
+	prefix := toParts(g.prefix)
+	parts := toParts(path)
+	for i, part := range prefix {
+		if part[0] == '*' {
+			return len(prefix), true
+		}
+		if i >= len(parts) {
+			return 0, false
+		}
+		if part[0] == ':' {
+			continue
+		}
+		if part != parts[i] && !(foldCase && strings.EqualFold(part, parts[i])) {
+			return 0, false
+		}
+	}
+	return len(prefix), true
+}
+
+func (g *group) addRoute(method string, comp string, handler HandlerFunc, middlewares []HandlerFunc) *route {
+	route := &route{
+		host:        g.host,
+		method:      method,
+		pattern:     g.prefix + comp,
+		handler:     handler,
+		middlewares: middlewares,
+		group:       g,
+	}
+	g.kid.router.addRoute(route)
+	return route
+}
+
+// Add adds a router with any method, including custom methods like PROPFIND.
+func (g *group) Add(method string, pattern string, handler HandlerFunc, middlewares ...HandlerFunc) *route {
+	return g.addRoute(strings.ToUpper(method), pattern, handler, middlewares)
 }
 
 // Head adds a head router.
-func (g *group) Head(pattern string, handler HandlerFunc) {
-	g.addRoute(http.MethodHead, pattern, handler)
+func (g *group) Head(pattern string, handler HandlerFunc, middlewares ...HandlerFunc) *route {
+	return g.addRoute(http.MethodHead, pattern, handler, middlewares)
 }
 
 // Get adds a get router.
-func (g *group) Get(pattern string, handler HandlerFunc) {
-	g.addRoute(http.MethodGet, pattern, handler)
+func (g *group) Get(pattern string, handler HandlerFunc, middlewares ...HandlerFunc) *route {
+	return g.addRoute(http.MethodGet, pattern, handler, middlewares)
 }
 
 // Delete adds a delete router.
-func (g *group) Delete(pattern string, handler HandlerFunc) {
-	g.addRoute(http.MethodDelete, pattern, handler)
+func (g *group) Delete(pattern string, handler HandlerFunc, middlewares ...HandlerFunc) *route {
+	return g.addRoute(http.MethodDelete, pattern, handler, middlewares)
 }
 
 // Post adds a post router.
-func (g *group) Post(pattern string, handler HandlerFunc) {
-	g.addRoute(http.MethodPost, pattern, handler)
+func (g *group) Post(pattern string, handler HandlerFunc, middlewares ...HandlerFunc) *route {
+	return g.addRoute(http.MethodPost, pattern, handler, middlewares)
 }
 
 // Put adds a put router.
-func (g *group) Put(pattern string, handler HandlerFunc) {
-	g.addRoute(http.MethodPut, pattern, handler)
+func (g *group) Put(pattern string, handler HandlerFunc, middlewares ...HandlerFunc) *route {
+	return g.addRoute(http.MethodPut, pattern, handler, middlewares)
 }
 
 // Patch adds a patch router.
-func (g *group) Patch(pattern string, handler HandlerFunc) {
-	g.addRoute(http.MethodPatch, pattern, handler)
+func (g *group) Patch(pattern string, handler HandlerFunc, middlewares ...HandlerFunc) *route {
+	return g.addRoute(http.MethodPatch, pattern, handler, middlewares)
+}
+
+// Options adds an options router.
+func (g *group) Options(pattern string, handler HandlerFunc, middlewares ...HandlerFunc) *route {
+	return g.addRoute(http.MethodOptions, pattern, handler, middlewares)
+}
+
+// Connect adds a connect router.
+func (g *group) Connect(pattern string, handler HandlerFunc, middlewares ...HandlerFunc) *route {
+	return g.addRoute(http.MethodConnect, pattern, handler, middlewares)
+}
+
+// Trace adds a trace router.
+func (g *group) Trace(pattern string, handler HandlerFunc, middlewares ...HandlerFunc) *route {
+	return g.addRoute(http.MethodTrace, pattern, handler, middlewares)
+}
+
+// Any adds a router for all standard methods.
+func (g *group) Any(pattern string, handler HandlerFunc, middlewares ...HandlerFunc) routes {
+	return g.Match(anyMethods, pattern, handler, middlewares...)
+}
+
+// Match adds a router for given methods.
+func (g *group) Match(methods []string, pattern string, handler HandlerFunc, middlewares ...HandlerFunc) routes {
+	routes := make(routes, 0, len(methods))
+	for _, method := range methods {
+		routes = append(routes, g.Add(method, pattern, handler, middlewares...))
+	}
+	return routes
+}
+
+// Handle serves requests of all standard methods under prefix with handler,
+// the prefix is stripped from the request path.
+func (g *group) Handle(prefix string, handler http.Handler, middlewares ...HandlerFunc) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	g.Any(prefix, stripPrefix(handler), middlewares...)
+	g.Any(prefix+"/*", stripPrefix(handler), middlewares...)
+}
+
+// Mount serves requests under prefix with a sub app, the prefix is stripped
+// from the request path.
+func (g *group) Mount(prefix string, app *Kid, middlewares ...HandlerFunc) {
+	g.Handle(prefix, app, middlewares...)
 }
 
-// Use adds a middleware.
+// Use adds middlewares.
+// Middlewares of the app are executed for every request, while middlewares of
+// a group are only executed for routes registered through the group.
 func (g *group) Use(middlewares ...HandlerFunc) {
-	g.kid.router.addMiddleware(g.prefix, middlewares...)
+	g.middlewares = append(g.middlewares, middlewares...)
+	g.kid.router.buildChains()
 }
@@ -3,33 +3,112 @@ package kid
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
-type handler struct {
-	kid *Kid
-}
+// ServeHTTP implements http.Handler, so kid app can be used by any http.Server,
+// httptest.NewServer or other routers.
+func (k *Kid) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	c := k.pool.Get().(*Ctx)
+	c.reset(k, w, req)
+	defer k.pool.Put(c)
+	defer c.removeMultipartForm(req)
+
+	route, canonical := k.router.getRoute(req.Host, c.Method(), c.Url().Path, &c.params)
 
-func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	c := newCtx(w, req)
-	handlerFunc, params, _ := h.kid.router.getRoute(c.Method(), c.Url().Path)
-	c.params = params
-	middlewares := h.kid.router.getMiddlewares(c.Url().Path)
-
-	handlers := append(middlewares, func(c *Ctx) error {
-		if handlerFunc != nil {
-			return handlerFunc(c)
-		} else {
-			return NewError(
-				http.StatusNotFound,
-				fmt.Sprintf("404 Not Found: %s %s", c.Method(), c.Url().RequestURI()),
-				nil,
-			)
+	// Serve HEAD request with GET handler and discard the body.
+	if route == nil && c.Method() == http.MethodHead {
+		route, canonical = k.router.getRoute(req.Host, http.MethodGet, c.Url().Path, &c.params)
+		if route != nil {
+			c.head = headWriter{ResponseWriter: w}
+			c.writer = &c.head
+			defer c.head.flush()
 		}
-	})
+	}
+
+	switch {
+	case k.config.BodyLimit > 0 && req.ContentLength > k.config.BodyLimit:
+		c.handlers = k.fallbackChain(req.Host, c.Url().Path, bodyTooLarge)
+	case route == nil:
+		c.handlers = k.fallbackChain(req.Host, c.Url().Path, k.notFound)
+	case canonical != c.Url().Path && k.config.PathPolicy == PathStrict:
+		c.handlers = k.fallbackChain(req.Host, c.Url().Path, k.notFound)
+	case canonical != c.Url().Path && k.config.PathPolicy == PathRedirect:
+		c.handlers = k.fallbackChain(req.Host, c.Url().Path, redirectTo(canonical))
+	default:
+		c.handlers = route.chain
+	}
 
-	c.handlers = handlers
 	err := c.Next()
 	if err != nil {
-		h.kid.config.ErrorHandler(c, err)
+		k.config.ErrorHandler(c, err)
+	}
+}
+
+// fallbackChain returns handler of requests that match no route, following middlewares
+// of the deepest group whose host and prefix match the request, so that 404, 405 and
+// OPTIONS answers under a group pass its middlewares, like CORS preflight.
+func (k *Kid) fallbackChain(host string, path string, handler HandlerFunc) []HandlerFunc {
+	host = hostname(host)
+	params := make([]param, 0)
+
+	deepest, depth := k.group, -1
+	for _, g := range k.groups {
+		n, ok := g.match(path, k.config.CaseInsensitive)
+		// Groups of a host are preferred to others of the same depth.
+		if !ok || n < depth || (n == depth && (g.host == "" || deepest.host != "")) {
+			continue
+		}
+		if g.host != "" {
+			h, _ := k.router.host(g.host)
+			if params = params[:0]; !h.match(host, &params) {
+				continue
+			}
+		}
+		deepest, depth = g, n
+	}
+
+	return append(deepest.chain(), handler)
+}
+
+// notFound answers requests that match no route.
+func (k *Kid) notFound(c *Ctx) error {
+	// Path exists under other methods, answer OPTIONS or return 405.
+	strict := k.config.PathPolicy == PathStrict
+	if allowed := k.router.allowedMethods(c.request.Host, c.Url().Path, strict); len(allowed) > 0 {
+		c.SetHeader(HeaderAllow, strings.Join(allowed, ", "))
+		if c.Method() == http.MethodOptions {
+			c.writer.WriteHeader(http.StatusNoContent)
+			return nil
+		}
+		return NewError(
+			http.StatusMethodNotAllowed,
+			fmt.Sprintf("405 Method Not Allowed: %s %s", c.Method(), c.Url().RequestURI()),
+			nil,
+		)
+	}
+
+	return NewError(
+		http.StatusNotFound,
+		fmt.Sprintf("404 Not Found: %s %s", c.Method(), c.Url().RequestURI()),
+		nil,
+	)
+}
+
+// bodyTooLarge answers requests whose Content-Length exceeds Config.BodyLimit.
+func bodyTooLarge(c *Ctx) error {
+	return ErrBodyTooLarge
+}
+
+// redirectTo redirects requests to the canonical path and keeps the query.
+func redirectTo(canonical string) HandlerFunc {
+	return func(c *Ctx) error {
+		target := &url.URL{Path: canonical, RawQuery: c.Url().RawQuery}
+		status := http.StatusPermanentRedirect
+		if c.Method() == http.MethodGet || c.Method() == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		return c.Redirect(target.String(), status)
 	}
 }
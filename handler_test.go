@@ -0,0 +1,37 @@
+package kid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimitRunsMiddlewares(t *testing.T) {
+	k := New(Config{BodyLimit: 4})
+	k.Use(func(c *Ctx) error {
+		c.SetHeader("X-Middleware", "1")
+		return c.Next()
+	})
+	api := k.Group("/api", func(c *Ctx) error {
+		c.SetHeader("X-Group", "1")
+		return c.Next()
+	})
+	api.Post("/x", func(c *Ctx) error {
+		t.Error("handler is called with a too large body")
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/x", strings.NewReader("too large"))
+	w := httptest.NewRecorder()
+	k.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	for _, header := range []string{"X-Middleware", "X-Group"} {
+		if w.Header().Get(header) != "1" {
+			t.Errorf("header %s is missing", header)
+		}
+	}
+}
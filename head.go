@@ -0,0 +1,41 @@
+package kid
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// headWriter is used when a GET handler serves a HEAD request.
+// It discards the body and counts written bytes to set Content-Length.
+type headWriter struct {
+	http.ResponseWriter
+
+	status int
+	length int
+}
+
+func (w *headWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.length += len(p)
+	return len(p), nil
+}
+
+// flush writes the status and headers to the underlying writer.
+func (w *headWriter) flush() {
+	if w.status == 0 {
+		return
+	}
+	header := w.ResponseWriter.Header()
+	if header.Get(HeaderContentLength) == "" {
+		header.Set(HeaderContentLength, strconv.Itoa(w.length))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
@@ -0,0 +1,103 @@
+package kid
+
+import (
+	"fmt"
+	"strings"
+)
+
+// hostRouter holds trees of routes registered for a host pattern, like
+// api.example.com or :tenant.example.com. The pattern of the default one is
+// empty and it matches any host.
+type hostRouter struct {
+	pattern string
+	labels  []string
+	trees   map[string]*routerTree
+}
+
+func newHostRouter(pattern string) (*hostRouter, error) {
+	h := &hostRouter{
+		pattern: strings.ToLower(pattern),
+		trees:   make(map[string]*routerTree),
+	}
+	if h.pattern == "" {
+		return h, nil
+	}
+
+	names := make(map[string]bool)
+	h.labels = strings.Split(h.pattern, ".")
+	for _, label := range h.labels {
+		if label == "" {
+			return nil, fmt.Errorf("empty label in host %s", pattern)
+		}
+		if label[0] == ':' {
+			if len(label) == 1 {
+				return nil, fmt.Errorf("param must be named with a non-empty name in host %s", pattern)
+			}
+			if names[label[1:]] {
+				return nil, fmt.Errorf("param name %s is used more than once in host %s", label[1:], pattern)
+			}
+			names[label[1:]] = true
+		}
+	}
+	return h, nil
+}
+
+// priority returns the match priority of host router, hosts with fewer
+// :param labels win, and the default one is the last.
+func (h *hostRouter) priority() int {
+	if h.pattern == "" {
+		return 1 << 16
+	}
+	wild := 0
+	for _, label := range h.labels {
+		if label[0] == ':' {
+			wild++
+		}
+	}
+	return wild
+}
+
+// match reports whether host matches the pattern and appends host params to
+// params.
+func (h *hostRouter) match(host string, params *[]param) bool {
+	if h.pattern == "" {
+		return true
+	}
+
+	start := len(*params)
+	rest := host
+	for i, label := range h.labels {
+		value := rest
+		if i < len(h.labels)-1 {
+			dot := strings.IndexByte(rest, '.')
+			if dot < 0 {
+				*params = (*params)[:start]
+				return false
+			}
+			value, rest = rest[:dot], rest[dot+1:]
+		} else if strings.IndexByte(rest, '.') >= 0 {
+			*params = (*params)[:start]
+			return false
+		}
+
+		if label[0] == ':' && value != "" {
+			*params = append(*params, param{key: label[1:], value: value})
+		} else if label != value {
+			*params = (*params)[:start]
+			return false
+		}
+	}
+	return true
+}
+
+// hostname returns host without port in lower case.
+func hostname(host string) string {
+	if strings.HasPrefix(host, "[") {
+		if end := strings.IndexByte(host, ']'); end > 0 {
+			host = host[1:end]
+		}
+	} else if colon := strings.IndexByte(host, ':'); colon >= 0 && colon == strings.LastIndexByte(host, ':') {
+		host = host[:colon]
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
@@ -1,7 +1,16 @@
 package kid
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
 )
 
 // HandlerFunc defines a function to serve HTTP requests.
@@ -12,12 +21,25 @@ type Kid struct {
 	*group
 	router *router
 	config Config
+	server *http.Server
+
+	// Pool of *Ctx.
+	pool sync.Pool
+
+	// Groups created by Group and Host, used to find middlewares of
+	// requests that match no route.
+	groups []*group
+
+	// Binders keyed by media type.
+	binders map[string]Binder
+
+	validator  *validator.Validate
+	translator *ut.UniversalTranslator
 }
 
 // New creates a kid app.
 func New(config ...Config) *Kid {
 	kid := &Kid{
-		router: newRouter(),
 		config: Config{},
 	}
 	kid.group = &group{kid: kid}
@@ -25,21 +47,136 @@ func New(config ...Config) *Kid {
 		kid.config = config[0]
 	}
 	setDefaultConfig(kid)
+	kid.router = newRouter(kid.config.CaseInsensitive)
+	kid.pool.New = func() interface{} {
+		return &Ctx{params: make([]param, 0, 8)}
+	}
+	kid.binders = make(map[string]Binder)
+	for mediaType, binder := range defaultBinders {
+		kid.binders[mediaType] = binder
+	}
+	kid.validator = newValidator()
+	kid.translator = newTranslator(kid.validator, kid.config.Locales)
+	kid.server = &http.Server{
+		Handler:           kid,
+		ReadTimeout:       kid.config.ReadTimeout,
+		ReadHeaderTimeout: kid.config.ReadHeaderTimeout,
+		WriteTimeout:      kid.config.WriteTimeout,
+		IdleTimeout:       kid.config.IdleTimeout,
+		MaxHeaderBytes:    kid.config.MaxHeaderBytes,
+	}
 	return kid
 }
 
+// Server returns the *http.Server owned by kid app.
+func (k *Kid) Server() *http.Server {
+	return k.server
+}
+
+// Host creates a router group for requests whose host matches pattern, like
+// api.example.com or :tenant.example.com whose params can be got by Ctx.Params.
+// Routes of hosts are matched before the ones registered without a host.
+func (k *Kid) Host(pattern string) *group {
+	if _, err := k.router.host(pattern); err != nil {
+		panic(fmt.Sprintf("kid: can not add host %s: %s", pattern, err))
+	}
+	g := &group{
+		host:   pattern,
+		kid:    k,
+		parent: k.group,
+	}
+	k.groups = append(k.groups, g)
+	return g
+}
+
+// Matcher registers a matcher that can be used as param constraint by name,
+// like :code<zipcode>. It must be called before routes using it are added,
+// otherwise adding them panics.
+func (k *Kid) Matcher(name string, matcher MatcherFunc) {
+	k.router.matchers[name] = matcher
+}
+
+// Binder registers a binder for media type used by Ctx.BodyParser, like
+// application/msgpack. Binders of built-in media types can be replaced too.
+func (k *Kid) Binder(mediaType string, binder Binder) {
+	k.binders[strings.ToLower(mediaType)] = binder
+}
+
+// Validator gets the validator used by Ctx.BodyParser and Ctx.Bind.
+func (k *Kid) Validator() *validator.Validate {
+	return k.validator
+}
+
+// RegisterValidation registers a custom validation by tag, like validate:"zipcode".
+func (k *Kid) RegisterValidation(tag string, fn validator.Func, callValidationEvenIfNull ...bool) {
+	if err := k.validator.RegisterValidation(tag, fn, callValidationEvenIfNull...); err != nil {
+		panic(fmt.Sprintf("kid: can not register validation %s: %s", tag, err))
+	}
+}
+
+// RegisterTranslation registers the message of validation tag in locale, which must be
+// one of Config.Locales or an alias of it, like zh-TW of zh_Hant_TW. {0} in text is
+// replaced by field's name and {1} by rule's param, like "{0} must be a valid zip code".
+func (k *Kid) RegisterTranslation(tag string, locale string, text string) {
+	name, ok := findLocale(locale)
+	if !ok {
+		panic(fmt.Sprintf("kid: locale %s is not supported", locale))
+	}
+	trans, ok := k.translator.GetTranslator(name)
+	if !ok {
+		panic(fmt.Sprintf("kid: locale %s is not in Config.Locales", locale))
+	}
+	err := k.validator.RegisterTranslation(tag, trans, func(t ut.Translator) error {
+		return t.Add(tag, text, true)
+	}, func(t ut.Translator, fe validator.FieldError) string {
+		message, _ := t.T(tag, fe.Field(), fe.Param())
+		return message
+	})
+	if err != nil {
+		panic(fmt.Sprintf("kid: can not register translation %s of locale %s: %s", tag, locale, err))
+	}
+}
+
+// URL builds url of the route named name with params and query.
+// It returns an error if the route does not exist, any param is missing or
+// does not match its constraint. Only the path and query are built, host of
+// routes added by Kid.Host is not included.
+func (k *Kid) URL(name string, params map[string]string, query url.Values) (string, error) {
+	return k.router.url(name, params, query)
+}
+
 // Listen starts server at addr.
 func (k *Kid) Listen(addr string) (err error) {
-	handler := &handler{
-		kid: k,
-	}
-	return http.ListenAndServe(addr, handler)
+	k.server.Addr = addr
+	return serverError(k.server.ListenAndServe())
 }
 
 // ListenTLS starts server at addr with https.
 func (k *Kid) ListenTLS(addr string, certFile string, keyFile string) (err error) {
-	handler := &handler{
-		kid: k,
+	k.server.Addr = addr
+	return serverError(k.server.ListenAndServeTLS(certFile, keyFile))
+}
+
+// Serve starts server with a listener.
+func (k *Kid) Serve(ln net.Listener) (err error) {
+	return serverError(k.server.Serve(ln))
+}
+
+// ServeTLS starts server with a listener with https.
+func (k *Kid) ServeTLS(ln net.Listener, certFile string, keyFile string) (err error) {
+	return serverError(k.server.ServeTLS(ln, certFile, keyFile))
+}
+
+// Shutdown gracefully shuts down the server, it stops accepting new connections
+// and waits for active requests to finish until ctx is done.
+// Listen, ListenTLS, Serve and ServeTLS return nil after Shutdown is called.
+func (k *Kid) Shutdown(ctx context.Context) error {
+	return k.server.Shutdown(ctx)
+}
+
+func serverError(err error) error {
+	if err == http.ErrServerClosed {
+		return nil
 	}
-	return http.ListenAndServeTLS(addr, certFile, keyFile, handler)
+	return err
 }
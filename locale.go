@@ -0,0 +1,174 @@
+package kid
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/ar"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fa"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/id"
+	"github.com/go-playground/locales/it"
+	"github.com/go-playground/locales/ja"
+	"github.com/go-playground/locales/nl"
+	"github.com/go-playground/locales/pt"
+	"github.com/go-playground/locales/pt_BR"
+	"github.com/go-playground/locales/ru"
+	"github.com/go-playground/locales/tr"
+	"github.com/go-playground/locales/vi"
+	"github.com/go-playground/locales/zh"
+	"github.com/go-playground/locales/zh_Hant_TW"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	ar_translations "github.com/go-playground/validator/v10/translations/ar"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fa_translations "github.com/go-playground/validator/v10/translations/fa"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+	it_translations "github.com/go-playground/validator/v10/translations/it"
+	ja_translations "github.com/go-playground/validator/v10/translations/ja"
+	nl_translations "github.com/go-playground/validator/v10/translations/nl"
+	pt_translations "github.com/go-playground/validator/v10/translations/pt"
+	pt_BR_translations "github.com/go-playground/validator/v10/translations/pt_BR"
+	ru_translations "github.com/go-playground/validator/v10/translations/ru"
+	tr_translations "github.com/go-playground/validator/v10/translations/tr"
+	vi_translations "github.com/go-playground/validator/v10/translations/vi"
+	zh_translations "github.com/go-playground/validator/v10/translations/zh"
+	zh_tw_translations "github.com/go-playground/validator/v10/translations/zh_tw"
+)
+
+// localeTranslation holds the locale and default validation translations of a supported locale.
+type localeTranslation struct {
+	locale   func() locales.Translator
+	register func(*validator.Validate, ut.Translator) error
+}
+
+// Supported locales keyed by name.
+var localeTranslations = map[string]localeTranslation{
+	"ar":         {ar.New, ar_translations.RegisterDefaultTranslations},
+	"en":         {en.New, en_translations.RegisterDefaultTranslations},
+	"es":         {es.New, es_translations.RegisterDefaultTranslations},
+	"fa":         {fa.New, fa_translations.RegisterDefaultTranslations},
+	"fr":         {fr.New, fr_translations.RegisterDefaultTranslations},
+	"id":         {id.New, id_translations.RegisterDefaultTranslations},
+	"it":         {it.New, it_translations.RegisterDefaultTranslations},
+	"ja":         {ja.New, ja_translations.RegisterDefaultTranslations},
+	"nl":         {nl.New, nl_translations.RegisterDefaultTranslations},
+	"pt":         {pt.New, pt_translations.RegisterDefaultTranslations},
+	"pt_BR":      {pt_BR.New, pt_BR_translations.RegisterDefaultTranslations},
+	"ru":         {ru.New, ru_translations.RegisterDefaultTranslations},
+	"tr":         {tr.New, tr_translations.RegisterDefaultTranslations},
+	"vi":         {vi.New, vi_translations.RegisterDefaultTranslations},
+	"zh":         {zh.New, zh_translations.RegisterDefaultTranslations},
+	"zh_Hant_TW": {zh_Hant_TW.New, zh_tw_translations.RegisterDefaultTranslations},
+}
+
+// localeAliases maps lowercased language tags to supported locales named differently,
+// like zh_tw to zh_Hant_TW.
+var localeAliases = map[string]string{
+	"zh_tw":      "zh_hant_tw",
+	"zh_hk":      "zh_hant_tw",
+	"zh_mo":      "zh_hant_tw",
+	"zh_hant":    "zh_hant_tw",
+	"zh_hant_hk": "zh_hant_tw",
+	"zh_hant_mo": "zh_hant_tw",
+	"zh_cn":      "zh",
+	"zh_sg":      "zh",
+	"zh_hans":    "zh",
+	"zh_hans_cn": "zh",
+	"zh_hans_sg": "zh",
+}
+
+// findLocale finds the supported locale of name or its alias case-insensitively,
+// like zh-hant-tw or zh-TW for zh_Hant_TW.
+func findLocale(name string) (string, bool) {
+	name = strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+	if alias, ok := localeAliases[name]; ok {
+		name = alias
+	}
+	for locale := range localeTranslations {
+		if strings.EqualFold(locale, name) {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// newTranslator creates a universal translator of names, the first one is the fallback,
+// and registers default validation translations of them to v.
+func newTranslator(v *validator.Validate, names []string) *ut.UniversalTranslator {
+	supported := make([]string, 0, len(names))
+	translators := make([]locales.Translator, 0, len(names))
+	for _, name := range names {
+		locale, ok := findLocale(name)
+		if !ok {
+			panic(fmt.Sprintf("kid: locale %s is not supported", name))
+		}
+		if contains(supported, locale) {
+			continue
+		}
+		supported = append(supported, locale)
+		translators = append(translators, localeTranslations[locale].locale())
+	}
+
+	uni := ut.New(translators[0], translators...)
+	for _, locale := range supported {
+		trans, _ := uni.GetTranslator(locale)
+		if err := localeTranslations[locale].register(v, trans); err != nil {
+			panic(fmt.Sprintf("kid: can not register translations of locale %s: %s", locale, err))
+		}
+	}
+	return uni
+}
+
+// acceptLanguages parses Accept-Language header to lowercased locale names ordered
+// by quality, like "zh-TW,en-US;q=0.8" to [zh_tw zh_hant_tw zh en_us en]. Each tag
+// is followed by its aliases and shorter forms.
+func acceptLanguages(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	languages := make([]language, 0)
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			f, err := strconv.ParseFloat(params[2:], 64)
+			if err != nil {
+				continue
+			}
+			quality = f
+		}
+		if quality <= 0 {
+			continue
+		}
+		languages = append(languages, language{strings.ToLower(strings.ReplaceAll(tag, "-", "_")), quality})
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	names := make([]string, 0, len(languages)*2)
+	for _, l := range languages {
+		subtags := strings.Split(l.tag, "_")
+		for i := len(subtags); i > 0; i-- {
+			name := strings.Join(subtags[:i], "_")
+			names = append(names, name)
+			if alias, ok := localeAliases[name]; ok {
+				names = append(names, alias)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,126 @@
+package kid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestAcceptLanguages(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"en", []string{"en"}},
+		{"EN-us", []string{"en_us", "en"}},
+		{"fr;q=0.5, de, en;q=0.8", []string{"de", "en", "fr"}},
+		{"en;q=0.5, fr;q=0.5", []string{"en", "fr"}},
+		{"ja, *;q=0.1, en;q=0, ru;q=abc", []string{"ja"}},
+		{"zh-TW", []string{"zh_tw", "zh_hant_tw", "zh"}},
+		{"zh-Hant-HK", []string{"zh_hant_hk", "zh_hant_tw", "zh_hant", "zh_hant_tw", "zh"}},
+		{"zh-CN,en-US;q=0.8", []string{"zh_cn", "zh", "zh", "en_us", "en"}},
+	}
+	for _, tt := range tests {
+		if got := acceptLanguages(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestFindLocale(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"en", "en", true},
+		{"EN", "en", true},
+		{"pt-br", "pt_BR", true},
+		{"zh-Hant-TW", "zh_Hant_TW", true},
+		{"zh-TW", "zh_Hant_TW", true},
+		{"zh-hk", "zh_Hant_TW", true},
+		{"zh-CN", "zh", true},
+		{"de", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := findLocale(tt.name); got != tt.want || ok != tt.ok {
+			t.Errorf("%s: got %q %v, want %q %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCtxLocale(t *testing.T) {
+	k := New(Config{Locales: []string{"en", "zh", "zh-TW"}})
+	k.Get("/", func(c *Ctx) error {
+		return c.String(c.Locale())
+	})
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"de", "en"},
+		{"zh-TW", "zh_Hant_TW"},
+		{"zh-Hant", "zh_Hant_TW"},
+		{"zh-CN", "zh"},
+		{"EN-us", "en"},
+		{"fr, zh-hk;q=0.5, en;q=0.8", "en"},
+		{"fr, zh-hk;q=0.9, en;q=0.8", "zh_Hant_TW"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderAcceptLanguage, tt.header)
+		w := httptest.NewRecorder()
+		k.ServeHTTP(w, req)
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterTranslation(t *testing.T) {
+	k := New(Config{Locales: []string{"en", "zh_Hant_TW"}})
+	k.RegisterValidation("zoo", func(fl validator.FieldLevel) bool {
+		return fl.Field().String() == "zoo"
+	})
+	k.RegisterTranslation("zoo", "zh-TW", "{0}必須是動物園")
+
+	type animal struct {
+		Place string `json:"place" validate:"zoo"`
+	}
+	k.Post("/", func(c *Ctx) error {
+		var a animal
+		return c.BodyParser(&a)
+	})
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"zh-TW", "place必須是動物園"},
+		{"en", "place failed on the 'zoo' rule"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"place":"park"}`))
+		req.Header.Set(HeaderContentType, "application/json")
+		req.Header.Set(HeaderAcceptLanguage, tt.header)
+		w := httptest.NewRecorder()
+		k.ServeHTTP(w, req)
+		if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%q: got %d %s, want %d containing %q", tt.header, w.Code, w.Body.String(), http.StatusUnprocessableEntity, tt.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic for a locale not in Config.Locales")
+		}
+	}()
+	k.RegisterTranslation("zoo", "fr", "{0} doit être un zoo")
+}
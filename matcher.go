@@ -0,0 +1,68 @@
+package kid
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MatcherFunc reports whether a path segment matches a param constraint.
+type MatcherFunc func(value string) bool
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+var alphaRegexp = regexp.MustCompile(`^[a-zA-Z]+$`)
+var identRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Matchers that can be used as param constraints by name, like :id<int>.
+var defaultMatchers = map[string]MatcherFunc{
+	"int": func(value string) bool {
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	},
+	"uint": func(value string) bool {
+		_, err := strconv.ParseUint(value, 10, 64)
+		return err == nil
+	},
+	"float": func(value string) bool {
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	},
+	"bool": func(value string) bool {
+		_, err := strconv.ParseBool(value)
+		return err == nil
+	},
+	"alpha": alphaRegexp.MatchString,
+	"uuid":  uuidRegexp.MatchString,
+}
+
+// parseParam splits a param part like :id<int> into its name and constraint.
+func parseParam(part string) (name string, constraint string) {
+	name = part[1:]
+	if start := strings.IndexByte(name, '<'); start >= 0 && strings.HasSuffix(name, ">") {
+		constraint = name[start+1 : len(name)-1]
+		name = name[:start]
+	}
+	return name, constraint
+}
+
+// newMatcher returns the matcher registered as constraint, or compiles
+// constraint as a regular expression that must match the whole segment.
+// A bare name like zipcode must be registered, so that a matcher registered
+// after the route is not silently used as a regular expression.
+func newMatcher(constraint string, matchers map[string]MatcherFunc) (MatcherFunc, error) {
+	if constraint == "" {
+		return nil, nil
+	}
+	if matcher, ok := matchers[constraint]; ok {
+		return matcher, nil
+	}
+	if identRegexp.MatchString(constraint) {
+		return nil, fmt.Errorf("matcher <%s> is not registered", constraint)
+	}
+	re, err := regexp.Compile("^(?:" + constraint + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid constraint <%s>: %s", constraint, err)
+	}
+	return re.MatchString, nil
+}
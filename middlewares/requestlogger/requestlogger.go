@@ -2,19 +2,40 @@ package requestlogger
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/Tarocch1/kid"
 )
 
+// DefaultFormatter logs method, uri and header of the request. The body is not
+// logged so that it is not buffered in memory, use BodyFormatter to log it.
 var DefaultFormatter = func(c *kid.Ctx) (string, map[string]interface{}) {
 	message := fmt.Sprintf("%s %s", c.Method(), c.Url().RequestURI())
 	extra := map[string]interface{}{
-		"body":   string(c.Body()),
 		"header": c.Header(),
 	}
 	return message, extra
 }
 
+// BodyFormatter returns a formatter that logs the body too, if Content-Length of
+// the request is known and not larger than limit bytes.
+func BodyFormatter(limit int64) func(c *kid.Ctx) (string, map[string]interface{}) {
+	return func(c *kid.Ctx) (string, map[string]interface{}) {
+		message, extra := DefaultFormatter(c)
+		length, err := strconv.ParseInt(c.GetHeader(kid.HeaderContentLength), 10, 64)
+		if err != nil || length > limit {
+			return message, extra
+		}
+		body, err := c.ReadBody()
+		if err != nil {
+			extra["body_error"] = err.Error()
+			return message, extra
+		}
+		extra["body"] = string(body)
+		return message, extra
+	}
+}
+
 type Config struct {
 	// Skip the middleware when this func return true.
 	//
@@ -27,6 +48,7 @@ type Config struct {
 	Module string
 
 	// Formatter formats ctx to log string.
+	// Use BodyFormatter to log bodies.
 	//
 	// Optional. Default: DefaultFormatter
 	Formatter func(c *kid.Ctx) (string, map[string]interface{})
@@ -0,0 +1,131 @@
+package kid
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// route is a registered handler with its middlewares.
+type route struct {
+	name        string
+	host        string
+	method      string
+	pattern     string
+	handler     HandlerFunc
+	middlewares []HandlerFunc
+
+	// The group which the route is registered through.
+	group *group
+
+	// Middlewares of the app, groups and route, followed by the handler.
+	chain []HandlerFunc
+}
+
+// buildChain builds the handler chain of route, including middlewares of the
+// app and its groups.
+func (r *route) buildChain() {
+	chain := r.group.chain()
+	chain = append(chain, r.middlewares...)
+	r.chain = append(chain, r.handler)
+}
+
+// Name sets the name of route, the name is used to build url by Kid.URL.
+func (r *route) Name(name string) *route {
+	r.group.kid.router.setName(name, r)
+	return r
+}
+
+// routes are registered by group.Match or group.Any.
+type routes []*route
+
+// Name sets the name of routes.
+func (rs routes) Name(name string) routes {
+	for _, r := range rs {
+		r.Name(name)
+	}
+	return rs
+}
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	// Host pattern, it is empty if the route matches any host.
+	Host    string
+	Method  string
+	Pattern string
+	Name    string
+
+	// Function name of the handler.
+	Handler string
+
+	// Function names of middlewares executed before the handler, including
+	// middlewares of the app and groups.
+	Middlewares []string
+}
+
+// funcName returns the full name of a function, or "-" if f is nil.
+func funcName(f interface{}) string {
+	v := reflect.ValueOf(f)
+	if f == nil || v.IsNil() {
+		return "-"
+	}
+	if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return "-"
+}
+
+// Routes returns all registered routes sorted by host, pattern and method.
+func (k *Kid) Routes() []RouteInfo {
+	infos := make([]RouteInfo, 0)
+	for _, tree := range k.router.allTrees() {
+		tree.walk(func(r *route) {
+			middlewares := make([]string, 0)
+			for _, m := range r.chain[:len(r.chain)-1] {
+				middlewares = append(middlewares, funcName(m))
+			}
+			infos = append(infos, RouteInfo{
+				Host:        r.host,
+				Method:      r.method,
+				Pattern:     toPattern(r.pattern),
+				Name:        r.name,
+				Handler:     funcName(r.handler),
+				Middlewares: middlewares,
+			})
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Host != infos[j].Host {
+			return infos[i].Host < infos[j].Host
+		}
+		if infos[i].Pattern != infos[j].Pattern {
+			return infos[i].Pattern < infos[j].Pattern
+		}
+		return infos[i].Method < infos[j].Method
+	})
+	return infos
+}
+
+// RoutesTable returns registered routes as a table, it can be printed at startup.
+func (k *Kid) RoutesTable() string {
+	var builder strings.Builder
+	w := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tMETHOD\tPATTERN\tNAME\tHANDLER\tMIDDLEWARES")
+	for _, info := range k.Routes() {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\n",
+			_if(info.Host != "", info.Host, "*"),
+			info.Method,
+			info.Pattern,
+			_if(info.Name != "", info.Name, "-"),
+			info.Handler,
+			_if(len(info.Middlewares) > 0, strings.Join(info.Middlewares, ", "), "-"),
+		)
+	}
+	w.Flush()
+	return builder.String()
+}
@@ -1,134 +1,641 @@
 package kid
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 )
 
-const middlewaresMethod = "middlewares"
+type nodeKind uint8
 
+const (
+	staticKind nodeKind = iota
+	paramKind
+	wildKind
+)
+
+// param is a path param or a host param.
+type param struct {
+	key   string
+	value string
+}
+
+// routerTreeNode is a node of compressed radix tree. A static node holds
+// bytes shared by its children, while a param node matches one segment and
+// a wildcard node matches one or more segments.
 type routerTreeNode struct {
-	pattern     string
-	part        string
-	children    []*routerTreeNode
-	isPartWild  bool
-	isFullWild  bool
-	middlewares []HandlerFunc
-	handler     HandlerFunc
+	kind nodeKind
+
+	// Bytes of static node, or the part of :param and *wildcard.
+	prefix string
+
+	// Pattern of routes which end at the node.
+	pattern string
+
+	staticChildren []*routerTreeNode
+	paramChildren  []*routerTreeNode
+	wildChildren   []*routerTreeNode
+	route          *route
+
+	// Name of :param or *wildcard, value of an unnamed wildcard is stored as "*".
+	name string
+
+	// Constraint of :param, like int in :id<int>.
+	constraint string
+	matcher    MatcherFunc
+
+	// Suffix of *wildcard, like .js in *path.js.
+	suffix string
 }
 
-func (n *routerTreeNode) matchChild(part string) *routerTreeNode {
-	for _, child := range n.children {
-		if child.part == part || child.isPartWild || child.isFullWild {
-			return child
+// lookup holds the state of a search.
+type lookup struct {
+	params   *[]param
+	foldCase bool
+
+	// Some static bytes are matched case-insensitively.
+	folded bool
+}
+
+// search finds the node with a route that matches path, static children win
+// over constrained :param<...>, which win over :param, which win over
+// *wildcard. It backtracks to the next child when a deeper branch fails.
+func (n *routerTreeNode) search(path string, l *lookup) *routerTreeNode {
+	if path == "" {
+		return _if(n.route != nil, n, nil)
+	}
+
+	for _, child := range n.staticChildren {
+		size := len(child.prefix)
+		if len(path) < size {
+			continue
+		}
+		if path[:size] == child.prefix {
+			if result := child.search(path[size:], l); result != nil {
+				return result
+			}
+		} else if l.foldCase && strings.EqualFold(path[:size], child.prefix) {
+			if result := child.search(path[size:], l); result != nil {
+				l.folded = true
+				return result
+			}
+		}
+	}
+
+	if len(n.paramChildren) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		value := path[:end]
+		for _, child := range n.paramChildren {
+			if child.matcher != nil && !child.matcher(value) {
+				continue
+			}
+			if result := child.search(path[end:], l); result != nil {
+				*l.params = append(*l.params, param{key: child.name, value: value})
+				return result
+			}
+		}
+	}
+
+	for _, child := range n.wildChildren {
+		// Wildcard consumes as many segments as possible.
+		for end := len(path); end > 0; end = strings.LastIndexByte(path[:end], '/') {
+			value := path[:end]
+			if child.suffix != "" {
+				last := value[strings.LastIndexByte(value, '/')+1:]
+				if len(last) <= len(child.suffix) || !strings.HasSuffix(last, child.suffix) {
+					continue
+				}
+			}
+			if result := child.search(path[end:], l); result != nil {
+				*l.params = append(*l.params, param{key: child.name, value: value[:len(value)-len(child.suffix)]})
+				return result
+			}
 		}
 	}
 	return nil
 }
 
+// insertStatic inserts static bytes under node, it splits the child sharing a
+// prefix with s and returns the node that ends with s.
+func (n *routerTreeNode) insertStatic(s string) *routerTreeNode {
+	if s == "" {
+		return n
+	}
+	for _, child := range n.staticChildren {
+		size := commonPrefix(child.prefix, s)
+		if size == 0 {
+			continue
+		}
+		if size < len(child.prefix) {
+			rest := *child
+			rest.prefix = child.prefix[size:]
+			*child = routerTreeNode{
+				kind:           staticKind,
+				prefix:         child.prefix[:size],
+				staticChildren: []*routerTreeNode{&rest},
+			}
+		}
+		return child.insertStatic(s[size:])
+	}
+
+	child := &routerTreeNode{kind: staticKind, prefix: s}
+	n.staticChildren = append(n.staticChildren, child)
+	return child
+}
+
+// insertWild inserts a :param or *wildcard child under node, it returns an
+// error if the child conflicts with an existing one.
+func (n *routerTreeNode) insertWild(part string, pattern string, matchers map[string]MatcherFunc) (*routerTreeNode, error) {
+	child := &routerTreeNode{prefix: part, pattern: pattern}
+	children := &n.paramChildren
+	if part[0] == ':' {
+		child.kind = paramKind
+		child.name, child.constraint = parseParam(part)
+	} else {
+		child.kind = wildKind
+		child.name, child.suffix = parseWildcard(part)
+		child.name = _if(child.name != "", child.name, "*")
+		children = &n.wildChildren
+	}
+
+	for _, c := range *children {
+		if c.prefix == part {
+			return c, nil
+		}
+		if c.constraint == child.constraint && c.suffix == child.suffix {
+			return nil, fmt.Errorf("wildcard %s conflicts with %s in existing pattern %s", part, c.prefix, c.pattern)
+		}
+	}
+
+	matcher, err := newMatcher(child.constraint, matchers)
+	if err != nil {
+		return nil, err
+	}
+	child.matcher = matcher
+
+	// Constrained params and suffixed wildcards are tried first.
+	index := len(*children)
+	if child.constraint == "" && child.suffix == "" {
+		*children = append(*children, child)
+		return child, nil
+	}
+	for i, c := range *children {
+		if c.constraint == "" && c.suffix == "" {
+			index = i
+			break
+		}
+	}
+	*children = append(*children, nil)
+	copy((*children)[index+1:], (*children)[index:])
+	(*children)[index] = child
+	return child, nil
+}
+
+// walk calls fn for node and all its descendants.
+func (n *routerTreeNode) walk(fn func(*routerTreeNode)) {
+	fn(n)
+	for _, children := range [][]*routerTreeNode{n.staticChildren, n.paramChildren, n.wildChildren} {
+		for _, child := range children {
+			child.walk(fn)
+		}
+	}
+}
+
 type routerTree struct {
 	root *routerTreeNode
 }
 
-func (t *routerTree) insert(parts []string, middleware bool, handler ...HandlerFunc) {
-	cur := t.root
-	pattern := ""
-	for _, part := range parts {
-		next := cur.matchChild(part)
-		pattern = pattern + "/" + part
-		if next == nil {
-			next = &routerTreeNode{
-				pattern:    pattern,
-				part:       part,
-				isPartWild: part[0] == ':',
-				isFullWild: part[0] == '*',
+// insert adds route at parts, it returns an error if parts conflict with
+// existing routes.
+func (t *routerTree) insert(parts []string, route *route, matchers map[string]MatcherFunc) error {
+	names := make(map[string]bool)
+	afterFullWild := false
+	for index, part := range parts {
+		isPartWild := part[0] == ':'
+		isFullWild := part[0] == '*'
+		var name string
+		if isPartWild {
+			name, _ = parseParam(part)
+			if name == "" {
+				return fmt.Errorf("param must be named with a non-empty name in segment %d", index+1)
 			}
-			cur.children = append(cur.children, next)
+		} else if isFullWild {
+			name, _ = parseWildcard(part)
 		}
-		cur = next
-	}
-	if middleware {
-		cur.middlewares = append(cur.middlewares, handler...)
-	} else {
-		cur.handler = handler[0]
+		if name != "" {
+			if names[name] {
+				return fmt.Errorf("param name %s is used more than once", name)
+			}
+			names[name] = true
+		}
+		if afterFullWild && (isPartWild || isFullWild) {
+			return fmt.Errorf("wildcard %s can not follow a catch-all, only static segments can", part)
+		}
+		afterFullWild = afterFullWild || isFullWild
 	}
-}
 
-func (t *routerTree) search(parts []string) (*routerTreeNode, []*routerTreeNode) {
-	nodes := []*routerTreeNode{t.root}
+	// Static parts are joined with slashes, like /users/ of /users/:id.
 	cur := t.root
-	for _, part := range parts {
-		next := cur.matchChild(part)
-		if next == nil {
-			return nil, nodes
+	static := "/"
+	for index, part := range parts {
+		if index > 0 {
+			static = static + "/"
+		}
+		if part[0] != ':' && part[0] != '*' {
+			static = static + part
+			continue
 		}
-		nodes = append(nodes, next)
-		if next.isFullWild {
-			return next, nodes
+		cur = cur.insertStatic(static)
+		static = ""
+
+		var err error
+		pattern := "/" + strings.Join(parts[:index+1], "/")
+		if cur, err = cur.insertWild(part, pattern, matchers); err != nil {
+			return err
 		}
-		cur = next
 	}
-	return cur, nodes
+	cur = cur.insertStatic(static)
+
+	if cur.route != nil {
+		return errors.New("handler is already registered")
+	}
+	cur.route = route
+	cur.pattern = "/" + strings.Join(parts, "/")
+	return nil
+}
+
+// walk calls fn for every route in tree, once for each route even if it is
+// inserted at several nodes by optional params.
+func (t *routerTree) walk(fn func(*route)) {
+	seen := make(map[*route]bool)
+	t.root.walk(func(n *routerTreeNode) {
+		if n.route != nil && !seen[n.route] {
+			seen[n.route] = true
+			fn(n.route)
+		}
+	})
 }
 
 type router struct {
-	trees map[string]*routerTree
+	// Host routers sorted by priority, the default one is the last.
+	hosts    []*hostRouter
+	names    map[string]*route
+	matchers map[string]MatcherFunc
+
+	// Match static parts case-insensitively.
+	caseInsensitive bool
+}
+
+func newRouter(caseInsensitive bool) *router {
+	matchers := make(map[string]MatcherFunc)
+	for name, matcher := range defaultMatchers {
+		matchers[name] = matcher
+	}
+	defaultHost, _ := newHostRouter("")
+	return &router{
+		hosts:    []*hostRouter{defaultHost},
+		names:    make(map[string]*route),
+		matchers: matchers,
+
+		caseInsensitive: caseInsensitive,
+	}
+}
+
+// host gets the host router of pattern, creates one if it does not exist.
+func (r *router) host(pattern string) (*hostRouter, error) {
+	for _, h := range r.hosts {
+		if h.pattern == strings.ToLower(pattern) {
+			return h, nil
+		}
+	}
+
+	h, err := newHostRouter(pattern)
+	if err != nil {
+		return nil, err
+	}
+	index := len(r.hosts)
+	for i, c := range r.hosts {
+		if c.priority() > h.priority() {
+			index = i
+			break
+		}
+	}
+	r.hosts = append(r.hosts, nil)
+	copy(r.hosts[index+1:], r.hosts[index:])
+	r.hosts[index] = h
+	return h, nil
 }
 
-func newRouter() *router {
-	return &router{trees: map[string]*routerTree{
-		http.MethodHead:   {root: &routerTreeNode{}},
-		http.MethodGet:    {root: &routerTreeNode{}},
-		http.MethodDelete: {root: &routerTreeNode{}},
-		http.MethodPost:   {root: &routerTreeNode{}},
-		http.MethodPut:    {root: &routerTreeNode{}},
-		http.MethodPatch:  {root: &routerTreeNode{}},
-		middlewaresMethod: {root: &routerTreeNode{}},
-	}}
+// tree gets the tree of host and method, creates one if it does not exist.
+func (r *router) tree(host string, method string) (*routerTree, error) {
+	h, err := r.host(host)
+	if err != nil {
+		return nil, err
+	}
+	tree, ok := h.trees[method]
+	if !ok {
+		tree = &routerTree{root: &routerTreeNode{}}
+		h.trees[method] = tree
+	}
+	return tree, nil
 }
 
-func (r *router) addRoute(method string, pattern string, handler HandlerFunc) {
-	parts := toParts(pattern)
-	r.trees[method].insert(parts, false, handler)
+func (r *router) addRoute(route *route) {
+	for _, parts := range expandOptional(toParts(route.pattern)) {
+		tree, err := r.tree(route.host, route.method)
+		if err == nil {
+			err = tree.insert(parts, route, r.matchers)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("kid: can not add route %s %s%s: %s", route.method, route.host, route.pattern, err))
+		}
+	}
+	route.buildChain()
 }
 
-func (r *router) getRoute(method string, path string) (HandlerFunc, map[string]string, []*routerTreeNode) {
-	pathParts := toParts(path)
-	tree, ok := r.trees[method]
+// allTrees returns trees of all hosts and methods.
+func (r *router) allTrees() []*routerTree {
+	trees := make([]*routerTree, 0)
+	for _, h := range r.hosts {
+		for _, tree := range h.trees {
+			trees = append(trees, tree)
+		}
+	}
+	return trees
+}
+
+// buildChains rebuilds handler chains of all routes, it is called after
+// middlewares are added.
+func (r *router) buildChains() {
+	for _, tree := range r.allTrees() {
+		tree.walk(func(route *route) {
+			route.buildChain()
+		})
+	}
+}
+
+// setName names route, routes with the same pattern can share a name.
+func (r *router) setName(name string, route *route) {
+	if named, ok := r.names[name]; ok && toPattern(named.pattern) != toPattern(route.pattern) {
+		panic(fmt.Sprintf("kid: route name %s is already used by %s", name, named.pattern))
+	}
+	route.name = name
+	r.names[name] = route
+}
+
+// url builds url of the route named name.
+func (r *router) url(name string, params map[string]string, query url.Values) (string, error) {
+	route, ok := r.names[name]
 	if !ok {
-		return nil, nil, nil
+		return "", fmt.Errorf("kid: route %s not found", name)
 	}
 
-	n, ns := tree.search(pathParts)
-	if n != nil {
-		parts := toParts(n.pattern)
-		params := make(map[string]string)
-		for index, part := range parts {
-			if part[0] == ':' {
-				params[part[1:]] = pathParts[index]
+	var builder strings.Builder
+	for _, part := range toParts(route.pattern) {
+		switch part[0] {
+		case ':':
+			optional := strings.HasSuffix(part, "?")
+			key, constraint := parseParam(strings.TrimSuffix(part, "?"))
+			value, ok := params[key]
+			if !ok || value == "" {
+				if optional {
+					continue
+				}
+				return "", fmt.Errorf("kid: param %s of route %s is missing", key, name)
+			}
+			matcher, err := newMatcher(constraint, r.matchers)
+			if err != nil {
+				return "", fmt.Errorf("kid: param %s of route %s: %s", key, name, err)
 			}
-			if part[0] == '*' && len(part) > 1 {
-				params[part[1:]] = strings.Join(pathParts[index:], "/")
-				break
+			if matcher != nil && !matcher(value) {
+				return "", fmt.Errorf("kid: param %s of route %s does not match <%s>", key, name, constraint)
 			}
+			builder.WriteString("/" + url.PathEscape(value))
+		case '*':
+			key, suffix := parseWildcard(part)
+			key = _if(key != "", key, "*")
+			value, ok := params[key]
+			if !ok || value == "" {
+				return "", fmt.Errorf("kid: param %s of route %s is missing", key, name)
+			}
+			segments := strings.Split(strings.Trim(value, "/"), "/")
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			builder.WriteString("/" + strings.Join(segments, "/") + suffix)
+		default:
+			builder.WriteString("/" + part)
 		}
-		return n.handler, params, ns
 	}
-	return nil, nil, ns
+
+	path := builder.String()
+	// Keep the trailing slash of the pattern, like /docs/.
+	if strings.HasSuffix(route.pattern, "/") {
+		path += "/"
+	}
+	if path == "" {
+		path = "/"
+	}
+	if len(query) > 0 {
+		path = path + "?" + query.Encode()
+	}
+	return path, nil
 }
 
-func (r *router) addMiddleware(pattern string, middlewares ...HandlerFunc) {
-	parts := toParts(pattern)
-	r.trees[middlewaresMethod].insert(parts, true, middlewares...)
+// getRoute finds the route matching host and path, it returns the route and
+// the canonical path of the request, and appends params of host and path to
+// params. Routes of matched hosts are tried by priority before the default ones.
+func (r *router) getRoute(host string, method string, path string, params *[]param) (*route, string) {
+	key, clean := routeKey(path)
+	host = hostname(host)
+	for _, h := range r.hosts {
+		tree, ok := h.trees[method]
+		if !ok {
+			continue
+		}
+		*params = (*params)[:0]
+		if !h.match(host, params) {
+			continue
+		}
+
+		l := lookup{params: params, foldCase: r.caseInsensitive}
+		if n := tree.root.search(key, &l); n != nil {
+			return n.route, canonicalPath(path, key, clean, n, &l)
+		}
+	}
+	*params = (*params)[:0]
+	return nil, ""
 }
 
-func (r *router) getMiddlewares(path string) []HandlerFunc {
-	middlewares := make([]HandlerFunc, 0)
-	_, _, ns := r.getRoute(middlewaresMethod, path)
-	for _, n := range ns {
-		middlewares = append(middlewares, n.middlewares...)
+// allowedMethods returns methods that have a handler matching host and path,
+// HEAD and OPTIONS are included when they are answered automatically.
+// Only routes whose canonical path is path are counted if strict is true.
+func (r *router) allowedMethods(host string, path string, strict bool) []string {
+	allowed := make([]string, 0)
+	methods := make(map[string]bool)
+	for _, h := range r.hosts {
+		for method := range h.trees {
+			methods[method] = true
+		}
+	}
+	params := make([]param, 0)
+	for method := range methods {
+		if route, canonical := r.getRoute(host, method, path, &params); route != nil && (!strict || canonical == path) {
+			allowed = append(allowed, method)
+		}
+	}
+	if contains(allowed, http.MethodGet) && !contains(allowed, http.MethodHead) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	if len(allowed) > 0 && !contains(allowed, http.MethodOptions) {
+		allowed = append(allowed, http.MethodOptions)
 	}
-	return middlewares
+	sort.Strings(allowed)
+	return allowed
+}
+
+// parseWildcard splits a wildcard part like *path.js into its name and suffix.
+func parseWildcard(part string) (name string, suffix string) {
+	end := 1
+	for end < len(part) && (part[end] == '_' ||
+		('a' <= part[end] && part[end] <= 'z') ||
+		('A' <= part[end] && part[end] <= 'Z') ||
+		('0' <= part[end] && part[end] <= '9')) {
+		end++
+	}
+	return part[1:end], part[end:]
+}
+
+// expandOptional expands parts with optional params like :name? into every
+// combination of parts with and without them.
+func expandOptional(parts []string) [][]string {
+	results := [][]string{{}}
+	for _, part := range parts {
+		optional := part[0] == ':' && strings.HasSuffix(part, "?")
+		required := strings.TrimSuffix(part, "?")
+		next := make([][]string, 0, len(results)*2)
+		for _, result := range results {
+			if optional {
+				next = append(next, result)
+			}
+			with := make([]string, len(result), len(result)+1)
+			copy(with, result)
+			next = append(next, append(with, _if(optional, required, part)))
+		}
+		results = next
+	}
+	return results
+}
+
+// routeKey returns the key to search routes, which is path without empty, "."
+// and ".." segments and the trailing slash. clean reports whether path has no
+// such segments, and the key is a substring of path if it is true.
+func routeKey(path string) (key string, clean bool) {
+	if !isCleanPath(path) {
+		return "/" + strings.Join(cleanParts(path), "/"), false
+	}
+	if len(path) > 1 && path[len(path)-1] == '/' {
+		return path[:len(path)-1], true
+	}
+	return path, true
+}
+
+// isCleanPath reports whether path starts with a slash and has no empty, "."
+// or ".." segments except an empty last one.
+func isCleanPath(path string) bool {
+	if path == "" || path[0] != '/' {
+		return false
+	}
+	start := 1
+	for i := 1; i <= len(path); i++ {
+		if i < len(path) && path[i] != '/' {
+			continue
+		}
+		segment := path[start:i]
+		if (segment == "" && i < len(path)) || segment == "." || segment == ".." {
+			return false
+		}
+		start = i + 1
+	}
+	return true
+}
+
+// cleanParts splits path to parts, empty and "." segments are dropped and
+// ".." segments remove the previous one.
+func cleanParts(path string) []string {
+	parts := make([]string, 0)
+	for _, item := range strings.Split(path, "/") {
+		switch item {
+		case "", ".":
+		case "..":
+			if len(parts) > 0 {
+				parts = parts[:len(parts)-1]
+			}
+		default:
+			parts = append(parts, item)
+		}
+	}
+	return parts
+}
+
+// canonicalPath returns the canonical path of a request that matches node n,
+// path is returned as it is if it is canonical already.
+func canonicalPath(path string, key string, clean bool, n *routerTreeNode, l *lookup) string {
+	trailing := key != "/" && strings.HasSuffix(n.route.pattern, "/")
+	if clean && !l.folded && (len(path) > len(key)) == trailing {
+		return path
+	}
+
+	canonical := key
+	if l.folded {
+		// Use static parts of the registered pattern.
+		parts := toParts(n.pattern)
+		for i, part := range parts {
+			switch part[0] {
+			case ':':
+				name, _ := parseParam(part)
+				parts[i] = getParam(*l.params, name)
+			case '*':
+				name, suffix := parseWildcard(part)
+				parts[i] = getParam(*l.params, _if(name != "", name, "*")) + suffix
+			}
+		}
+		canonical = "/" + strings.Join(parts, "/")
+	}
+	if trailing {
+		canonical = canonical + "/"
+	}
+	return canonical
+}
+
+// getParam gets the value of param by key.
+func getParam(params []param, key string) string {
+	for _, p := range params {
+		if p.key == key {
+			return p.value
+		}
+	}
+	return ""
+}
+
+// commonPrefix returns the length of the common prefix of a and b.
+func commonPrefix(a string, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// toPattern returns the normalized form of pattern.
+func toPattern(pattern string) string {
+	return "/" + strings.Join(toParts(pattern), "/")
 }
 
 func toParts(pattern string) []string {
@@ -137,9 +644,6 @@ func toParts(pattern string) []string {
 	for _, item := range vs {
 		if item != "" {
 			parts = append(parts, item)
-			if item[0] == '*' {
-				break
-			}
 		}
 	}
 	return parts
@@ -0,0 +1,185 @@
+package kid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func benchmarkRouter(b *testing.B, pattern string, path string) {
+	k := New()
+	k.Get(pattern, func(c *Ctx) error {
+		return nil
+	})
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkRouterStatic(b *testing.B) {
+	benchmarkRouter(b, "/users/profile/settings", "/users/profile/settings")
+}
+
+func BenchmarkRouterParam(b *testing.B) {
+	benchmarkRouter(b, "/users/:id/posts/:post", "/users/42/posts/7")
+}
+
+func BenchmarkRouterWildcard(b *testing.B) {
+	benchmarkRouter(b, "/static/*path", "/static/css/app/main.css")
+}
+
+func TestRouterPriority(t *testing.T) {
+	noop := func(c *Ctx) error {
+		return nil
+	}
+	k := New()
+	for _, pattern := range []string{
+		"/users/new",
+		"/users/:id<int>",
+		"/users/:name",
+		"/users/:name/posts",
+		"/files/*path.js",
+		"/files/*path",
+		"/a/:x/c",
+		"/a/b/d",
+		"/repos/*path/edit",
+	} {
+		k.Get(pattern, noop)
+	}
+
+	tests := []struct {
+		path    string
+		pattern string
+		params  map[string]string
+	}{
+		{"/users/new", "/users/new", map[string]string{}},
+		{"/users/42", "/users/:id<int>", map[string]string{"id": "42"}},
+		{"/users/bob", "/users/:name", map[string]string{"name": "bob"}},
+		{"/users/42/posts", "/users/:name/posts", map[string]string{"name": "42"}},
+		{"/files/js/app.js", "/files/*path.js", map[string]string{"path": "js/app"}},
+		{"/files/css/app.css", "/files/*path", map[string]string{"path": "css/app.css"}},
+		{"/a/b/c", "/a/:x/c", map[string]string{"x": "b"}},
+		{"/a/b/d", "/a/b/d", map[string]string{}},
+		{"/repos/a/b/edit", "/repos/*path/edit", map[string]string{"path": "a/b"}},
+		{"/users", "", nil},
+		{"/a/b/e", "", nil},
+	}
+	for _, tt := range tests {
+		params := make([]param, 0)
+		route, _ := k.router.getRoute("example.com", http.MethodGet, tt.path, &params)
+		if tt.pattern == "" {
+			if route != nil {
+				t.Errorf("%s: got %s, want no route", tt.path, route.pattern)
+			}
+			continue
+		}
+		if route == nil {
+			t.Errorf("%s: got no route, want %s", tt.path, tt.pattern)
+			continue
+		}
+		if route.pattern != tt.pattern {
+			t.Errorf("%s: got %s, want %s", tt.path, route.pattern, tt.pattern)
+		}
+		if len(params) != len(tt.params) {
+			t.Errorf("%s: got params %v, want %v", tt.path, params, tt.params)
+		}
+		for key, value := range tt.params {
+			if got := getParam(params, key); got != value {
+				t.Errorf("%s: got param %s=%q, want %q", tt.path, key, got, value)
+			}
+		}
+	}
+}
+
+func TestRouterConflicts(t *testing.T) {
+	noop := func(c *Ctx) error {
+		return nil
+	}
+	tests := []struct {
+		name     string
+		patterns []string
+		panics   bool
+	}{
+		{"different params", []string{"/users/:id", "/users/:name"}, true},
+		{"different wildcards", []string{"/files/*a", "/files/*b"}, true},
+		{"same pattern", []string{"/users/:id", "/users/:id"}, true},
+		{"empty param name", []string{"/users/:"}, true},
+		{"duplicate param name", []string{"/users/:id/posts/:id"}, true},
+		{"param after catch-all", []string{"/files/*path/:id"}, true},
+		{"ambiguous optionals", []string{"/u/:a?/:b?"}, true},
+		{"unregistered matcher", []string{"/z/:code<zipcode>"}, true},
+		{"regexp constraint", []string{"/z/:code<[0-9]{5}>"}, false},
+		{"constrained and plain param", []string{"/users/:id<int>", "/users/:name"}, false},
+		{"static and param", []string{"/users/new", "/users/:id"}, false},
+		{"suffixed and plain wildcard", []string{"/files/*path.js", "/files/*path"}, false},
+		{"static after catch-all", []string{"/repos/*path/edit"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.panics {
+					t.Errorf("got panic %v, want panic %v", r, tt.panics)
+				}
+			}()
+			k := New()
+			for _, pattern := range tt.patterns {
+				k.Get(pattern, noop)
+			}
+		})
+	}
+}
+
+func TestRouterAllocs(t *testing.T) {
+	k := New()
+	k.Get("/users/:id/files/*path", func(c *Ctx) error {
+		return nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/users/42/files/a/b.txt", nil)
+	w := httptest.NewRecorder()
+
+	if allocs := testing.AllocsPerRun(100, func() { k.ServeHTTP(w, req) }); allocs != 0 {
+		t.Errorf("got %v allocs per request, want 0", allocs)
+	}
+}
+
+func TestRouterURL(t *testing.T) {
+	noop := func(c *Ctx) error {
+		return nil
+	}
+	k := New()
+	k.Get("/docs/", noop).Name("docs")
+	k.Get("/docs/:id<int>", noop).Name("doc")
+	k.Get("/posts/:year/:slug?", noop).Name("post")
+	k.Get("/static/*path", noop).Name("static")
+	k.Host(":tenant.example.com").Get("/home", noop).Name("home")
+
+	tests := []struct {
+		name    string
+		params  map[string]string
+		query   url.Values
+		want    string
+		wantErr bool
+	}{
+		{"docs", nil, nil, "/docs/", false},
+		{"doc", map[string]string{"id": "42"}, url.Values{"v": {"1"}}, "/docs/42?v=1", false},
+		{"doc", map[string]string{"id": "abc"}, nil, "", true},
+		{"doc", nil, nil, "", true},
+		{"post", map[string]string{"year": "2024"}, nil, "/posts/2024", false},
+		{"post", map[string]string{"year": "2024", "slug": "a b"}, nil, "/posts/2024/a%20b", false},
+		{"static", map[string]string{"path": "css/app.css"}, nil, "/static/css/app.css", false},
+		{"home", map[string]string{"tenant": "acme"}, nil, "/home", false},
+		{"missing", nil, nil, "", true},
+	}
+	for _, tt := range tests {
+		got, err := k.URL(tt.name, tt.params, tt.query)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s %v: got %q, %v, want %q, error %v", tt.name, tt.params, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
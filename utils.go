@@ -1,8 +1,6 @@
 package kid
 
 import (
-	"fmt"
-	"net/url"
 	"reflect"
 )
 
@@ -23,6 +21,15 @@ func sliceFilter[T interface{}](s []T, filter func(item T) bool) []T {
 	return newS
 }
 
+func contains[T comparable](s []T, target T) bool {
+	for _, x := range s {
+		if x == target {
+			return true
+		}
+	}
+	return false
+}
+
 func isZero[T interface{}](value T, defaultValue ...T) bool {
 	v := reflect.ValueOf(value)
 	return v.IsZero()
@@ -37,30 +44,3 @@ func getValue[T interface{}](value T, defaultValue ...T) T {
 		return value
 	}
 }
-
-func unmarshalForm(data url.Values, out interface{}) (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			var ok bool
-			if err, ok = r.(error); !ok {
-				err = fmt.Errorf("%v", r)
-			}
-		}
-	}()
-
-	outValue := reflect.ValueOf(out).Elem()
-	outType := reflect.TypeOf(out).Elem()
-	for i := 0; i < outValue.NumField(); i++ {
-		field := outValue.Field(i)
-		tag := outType.Field(i).Tag.Get("form")
-		item, ok := data[tag]
-		if ok {
-			if field.Type().Kind() == reflect.String {
-				field.SetString(item[0])
-			} else {
-				field.Set(reflect.ValueOf(item))
-			}
-		}
-	}
-	return nil
-}
@@ -0,0 +1,83 @@
+package kid
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes a field failed on validation.
+type FieldError struct {
+	// Path of the field named by json or form tag, like addr.city or items[0].name
+	Field string `json:"field"`
+
+	// Validation tag failed on, like required or min
+	Rule string `json:"rule"`
+
+	// Param of the rule, like 3 of min=3
+	Param string `json:"param"`
+
+	// Message to show
+	Message string `json:"message"`
+}
+
+// newValidator creates a validator naming fields by json or form tag.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(fieldName)
+	return v
+}
+
+// fieldName gets the name of a struct field by json or form tag, falls back to field's name.
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// validationError converts the error returned by validator to *kid.Error with status 422,
+// Data of which is []FieldError with messages translated by trans. Other errors are
+// returned as they are.
+func validationError(err error, trans ut.Translator) error {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		field := fe.Namespace()
+		// Strip the name of top-level struct.
+		if i := strings.IndexByte(field, '.'); i >= 0 {
+			field = field[i+1:]
+		}
+		fields = append(fields, FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fieldErrorMessage(field, fe, trans),
+		})
+	}
+	return NewError(http.StatusUnprocessableEntity, "422 Unprocessable Entity", fields)
+}
+
+// fieldErrorMessage gets the translated message of a field error,
+// falls back to a default message if the rule has no translation.
+func fieldErrorMessage(field string, fe validator.FieldError, trans ut.Translator) string {
+	if message := fe.Translate(trans); message != fe.Error() {
+		return message
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("%s failed on the '%s=%s' rule", field, fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("%s failed on the '%s' rule", field, fe.Tag())
+}
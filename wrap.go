@@ -0,0 +1,55 @@
+package kid
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// WrapHandler wraps http.Handler as kid.HandlerFunc.
+func WrapHandler(handler http.Handler) HandlerFunc {
+	return func(c *Ctx) error {
+		handler.ServeHTTP(c.writer, c.request)
+		return nil
+	}
+}
+
+// WrapMiddleware wraps net/http middleware as kid.HandlerFunc.
+// The next handlers are executed with the writer and request passed by the middleware,
+// and their errors are handled by Config.ErrorHandler before the middleware returns,
+// so that error responses are written through the writer of the middleware too.
+func WrapMiddleware(middleware func(http.Handler) http.Handler) HandlerFunc {
+	return func(c *Ctx) error {
+		writer, request := c.writer, c.request
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c.writer = w
+			c.request = r
+			if err := c.Next(); err != nil {
+				c.kid.config.ErrorHandler(c, err)
+			}
+			c.writer = writer
+			c.request = request
+		})
+		middleware(next).ServeHTTP(writer, request)
+		return nil
+	}
+}
+
+// stripPrefix serves handler with the path matched by the unnamed wildcard.
+func stripPrefix(handler http.Handler) HandlerFunc {
+	return func(c *Ctx) error {
+		path := "/" + c.GetParam("*")
+		if path != "/" && c.Url().Path[len(c.Url().Path)-1] == '/' {
+			path = path + "/"
+		}
+
+		r := new(http.Request)
+		*r = *c.request
+		r.URL = new(url.URL)
+		*r.URL = *c.request.URL
+		r.URL.Path = path
+		r.URL.RawPath = ""
+
+		handler.ServeHTTP(c.writer, r)
+		return nil
+	}
+}
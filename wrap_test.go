@@ -0,0 +1,69 @@
+package kid
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// bufferWriter buffers the body until the middleware flushes it, like gzip writers.
+type bufferWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+func (w *bufferWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func bufferMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bw := &bufferWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(bw, r)
+		w.WriteHeader(bw.status)
+		w.Write([]byte(strings.ToUpper(bw.buf.String())))
+	})
+}
+
+func TestWrapMiddleware(t *testing.T) {
+	k := New()
+	var writer http.ResponseWriter
+	k.Use(func(c *Ctx) error {
+		writer = c.writer
+		err := c.Next()
+		if c.writer != writer {
+			t.Error("writer is not restored after the middleware returns")
+		}
+		return err
+	})
+	k.Use(WrapMiddleware(bufferMiddleware))
+	k.Get("/ok", func(c *Ctx) error {
+		return c.String("ok")
+	})
+	k.Get("/error", func(c *Ctx) error {
+		return NewError(http.StatusTeapot, "teapot", nil)
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/ok", http.StatusOK, "OK"},
+		{"/error", http.StatusTeapot, "TEAPOT"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		k.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.path, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+}